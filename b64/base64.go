@@ -2,9 +2,17 @@ package b64
 
 import (
 	"encoding/base64"
+	"errors"
+	"io"
 	"strings"
 )
 
+//ErrMalformedInput means that the input is not a canonical, unpadded base64url encoding.
+var ErrMalformedInput = errors.New("malformed base64url input")
+
+//strict is the base64url alphabet without padding that rejects non zero trailing bits.
+var strict = base64.RawURLEncoding.Strict()
+
 //EncodeURL the data to base64 without padding and url safe
 func EncodeURL(data []byte) string {
 	return strings.TrimRight(base64.URLEncoding.EncodeToString(data), "=")
@@ -21,11 +29,62 @@ func DecodeURL(urlenc string) []byte {
 		return data
 	}
 
-	if e, ok := err.(base64.CorruptInputError); ok {
-		panic(e.Error())
+	return nil
+}
+
+//DecodeURLStrict decodes an unpadded base64url string as found in a JOSE object.
+//Padding, whitespace, line breaks and non canonical trailing bits are rejected,
+//so it's the one to be used with untrusted input.
+func DecodeURLStrict(urlenc string) ([]byte, error) {
+
+	if strings.ContainsAny(urlenc, "\r\n") {
+		return nil, ErrMalformedInput
 	}
 
-	return nil
+	data, err := strict.DecodeString(urlenc)
+	if err != nil {
+		return nil, ErrMalformedInput
+	}
+	return data, nil
+}
+
+//NewEncoder returns a stream encoder: the data written to it is written to w
+//encoded as unpadded base64url. It must be closed to flush the last partial block.
+func NewEncoder(w io.Writer) io.WriteCloser {
+	return base64.NewEncoder(base64.RawURLEncoding, w)
+}
+
+//NewDecoder returns a stream decoder of the unpadded base64url data read from r,
+//as strict as DecodeURLStrict.
+func NewDecoder(r io.Reader) io.Reader {
+	return &decoder{dec: base64.NewDecoder(strict, lineBreakGuard{r})}
+}
+
+type decoder struct {
+	dec io.Reader
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	n, err := d.dec.Read(p)
+	if _, ok := err.(base64.CorruptInputError); ok {
+		err = ErrMalformedInput
+	}
+	return n, err
+}
+
+//lineBreakGuard fails on the line breaks that base64.NewDecoder would otherwise skip.
+type lineBreakGuard struct {
+	r io.Reader
+}
+
+func (g lineBreakGuard) Read(p []byte) (int, error) {
+	n, err := g.r.Read(p)
+	for _, c := range p[:n] {
+		if c == '\r' || c == '\n' {
+			return 0, ErrMalformedInput
+		}
+	}
+	return n, err
 }
 
 func undoTrim(str string) string {
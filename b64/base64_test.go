@@ -2,6 +2,9 @@ package b64
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 )
 
@@ -33,3 +36,91 @@ func Test_B64_DifferentPaddings(t *testing.T) {
 	}
 
 }
+
+func Test_B64_Strict(t *testing.T) {
+
+	var valid = map[string]string{
+		``:     ``,
+		`YQ`:   `a`,
+		`YWI`:  `ab`,
+		`YWJj`: `abc`,
+		`-_8`:  "\xfb\xff",
+	}
+
+	for enc, dec := range valid {
+		data, err := DecodeURLStrict(enc)
+		if err != nil {
+			t.Errorf("%q: %v", enc, err)
+		} else if string(data) != dec {
+			t.Errorf("%q: expected %q, found %q", enc, dec, data)
+		}
+	}
+
+	var invalid = []string{
+		`YQ==`,     //padding
+		`YWI=`,     //padding
+		`YR`,       //non zero trailing bits
+		`YWJ`,      //non zero trailing bits
+		`YW Jj`,    //whitespace
+		"YW\nJj",   //line break
+		`YWJj+/8A`, //standard alphabet
+		`Y`,        //impossible length
+	}
+
+	for _, enc := range invalid {
+		if _, err := DecodeURLStrict(enc); err == nil {
+			t.Errorf("%q: missed error", enc)
+		} else if !errors.Is(err, ErrMalformedInput) {
+			t.Errorf("%q: Expected %s, found %v", enc, ErrMalformedInput, err)
+		}
+	}
+}
+
+func Test_B64_CorruptDoesNotPanic(t *testing.T) {
+	if data := DecodeURL(`%%%`); data != nil {
+		t.Errorf("Expected nil, found %v", data)
+	}
+}
+
+func Test_B64_Stream(t *testing.T) {
+
+	var data = make([]byte, 10000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	//Uneven writes
+	for i := 0; i < len(data); i += 333 {
+		end := i + 333
+		if end > len(data) {
+			end = len(data)
+		}
+		enc.Write(data[i:end])
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != EncodeURL(data) {
+		t.Fatal("different encodings")
+	}
+
+	decoded, err := io.ReadAll(NewDecoder(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decoded, data) {
+		t.Error("different data")
+	}
+
+	for _, enc := range []string{"YWJj\nYWJj", `YWJj====`, `YR`} {
+		if _, err := io.ReadAll(NewDecoder(strings.NewReader(enc))); err == nil {
+			t.Errorf("%q: missed error", enc)
+		} else if !errors.Is(err, ErrMalformedInput) {
+			t.Errorf("%q: Expected %s, found %v", enc, ErrMalformedInput, err)
+		}
+	}
+}
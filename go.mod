@@ -0,0 +1,5 @@
+module github.com/vegaj/JOSE
+
+//crypto/mldsa, used by the ML-DSA signatures and the AKP keys, needs Go 1.27.
+//The raw key parsers of crypto/ecdsa, used by the EC keys of jwk, need Go 1.25.
+go 1.27
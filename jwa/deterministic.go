@@ -0,0 +1,129 @@
+package jwa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"encoding/asn1"
+	"io"
+	"math/big"
+)
+
+type deterministicSigner struct {
+	priv *ecdsa.PrivateKey
+}
+
+//DeterministicSigner returns a crypto.Signer that signs with priv using the nonces of RFC 6979,
+//so the same digest always produces the same signature: https://tools.ietf.org/html/rfc6979
+//The random source given to Sign is ignored. It can be given to EllipticSignDigest as any other key.
+func DeterministicSigner(priv *ecdsa.PrivateKey) crypto.Signer {
+	return deterministicSigner{priv: priv}
+}
+
+//Public implements crypto.Signer.
+func (d deterministicSigner) Public() crypto.PublicKey {
+	return &d.priv.PublicKey
+}
+
+//Sign implements crypto.Signer. The signature is ASN.1 encoded, as the one of ecdsa.PrivateKey.
+func (d deterministicSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+
+	if opts == nil || opts.HashFunc() == 0 || !opts.HashFunc().Available() || len(digest) != opts.HashFunc().Size() {
+		return nil, ErrInvalidInput
+	}
+
+	//crypto/ecdsa implements RFC 6979 for the NIST curves, in constant time.
+	switch d.priv.Curve {
+	case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return d.priv.Sign(nil, digest, opts)
+	}
+
+	r, s, err := signRFC6979(d.priv, digest, opts.HashFunc())
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+}
+
+//signRFC6979 is the ECDSA signature of https://tools.ietf.org/html/rfc6979#section-3.2
+//for any curve. It's implemented with math/big, so it's not constant time.
+func signRFC6979(priv *ecdsa.PrivateKey, digest []byte, h crypto.Hash) (r, s *big.Int, err error) {
+
+	var q = priv.Curve.Params().N
+	if priv.D == nil || priv.D.Sign() <= 0 || priv.D.Cmp(q) >= 0 {
+		return nil, nil, ErrInvalidKey
+	}
+
+	var qlen = q.BitLen()
+	var rlen = (qlen + 7) / 8
+
+	var bits2int = func(b []byte) *big.Int {
+		var v = new(big.Int).SetBytes(b)
+		if excess := len(b)*8 - qlen; excess > 0 {
+			v.Rsh(v, uint(excess))
+		}
+		return v
+	}
+
+	var int2octets = func(v *big.Int) []byte {
+		return v.FillBytes(make([]byte, rlen))
+	}
+
+	//bits2octets
+	var e = bits2int(digest)
+	var h1 = new(big.Int).Set(e)
+	if h1.Cmp(q) >= 0 {
+		h1.Sub(h1, q)
+	}
+
+	var mac = func(key []byte, data ...[]byte) []byte {
+		var m = hmac.New(h.New, key)
+		for _, d := range data {
+			m.Write(d)
+		}
+		return m.Sum(nil)
+	}
+
+	//Steps b to g
+	var v = make([]byte, h.Size())
+	var k = make([]byte, h.Size())
+	for i := range v {
+		v[i] = 0x01
+	}
+
+	var x, hashed = int2octets(priv.D), int2octets(h1)
+	k = mac(k, v, []byte{0x00}, x, hashed)
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, x, hashed)
+	v = mac(k, v)
+
+	//Step h
+	for {
+		var t []byte
+		for len(t)*8 < qlen {
+			v = mac(k, v)
+			t = append(t, v...)
+		}
+
+		var nonce = bits2int(t)
+		if nonce.Sign() > 0 && nonce.Cmp(q) < 0 {
+			r, _ = priv.Curve.ScalarBaseMult(int2octets(nonce))
+			r.Mod(r, q)
+
+			if r.Sign() != 0 {
+				//s = k⁻¹(e + xr) mod q
+				s = new(big.Int).Mul(priv.D, r)
+				s.Add(s, e)
+				s.Mul(s, new(big.Int).ModInverse(nonce, q))
+				s.Mod(s, q)
+				if s.Sign() != 0 {
+					return r, s, nil
+				}
+			}
+		}
+
+		k = mac(k, v, []byte{0x00})
+		v = mac(k, v)
+	}
+}
@@ -0,0 +1,98 @@
+package jwa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+//RFC 6979 Appendix A.2.5 (P-256), A.2.6 (P-384) and A.2.7 (P-521): https://tools.ietf.org/html/rfc6979#appendix-A.2.5
+var rfc6979Vectors = []struct {
+	curve   elliptic.Curve
+	alg     Algorithm
+	x       string
+	message string
+	r, s    string
+}{
+	{elliptic.P256(), ES256, "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721", "sample",
+		"EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716",
+		"F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8"},
+	{elliptic.P256(), ES256, "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721", "test",
+		"F1ABB023518351CD71D881567B1EA663ED3EFCF6C5132B354F28D3B0B7D38367",
+		"019F4113742A2B14BD25926B49C649155F267E60D3814B4C0CC84250E46F0083"},
+	{elliptic.P384(), ES384, "6B9D3DAD2E1B8C1C05B19875B6659F4DE23C3B667BF297BA9AA47740787137D896D5724E4C70A825F872C9EA60D2EDF5", "sample",
+		"94EDBB92A5ECB8AAD4736E56C691916B3F88140666CE9FA73D64C4EA95AD133C81A648152E44ACF96E36DD1E80FABE46",
+		"99EF4AEB15F178CEA1FE40DB2603138F130E740A19624526203B6351D0A3A94FA329C145786E679E7B82C71A38628AC8"},
+	{elliptic.P521(), ES512, "0FAD06DAA62BA3B25D2FB40133DA757205DE67F5BB0018FEE8C86E1B68C7E75CAA896EB32F1F47C70855836A6D16FCC1466F6D8FBEC67DB89EC0C08B0E996B83538", "sample",
+		"0C328FAFCBD79DD77850370C46325D987CB525569FB63C5D3BC53950E6D4C5F174E25A1EE9017B5D450606ADD152B534931D7D4E8455CC91F9B15BF05EC36E377FA",
+		"0617CCE7CF5064806C467F678D3B4080D6F1CC50AF26CA209417308281B68AF282623EAA63E5B5C0723D8B8C37FF0777B1A20F8CCB1DCCC43997F1EE0E44DA4A67A"},
+	{elliptic.P521(), ES512, "0FAD06DAA62BA3B25D2FB40133DA757205DE67F5BB0018FEE8C86E1B68C7E75CAA896EB32F1F47C70855836A6D16FCC1466F6D8FBEC67DB89EC0C08B0E996B83538", "test",
+		"13E99020ABF5CEE7525D16B69B229652AB6BDF2AFFCAEF38773B4B7D08725F10CDB93482FDCC54EDCEE91ECA4166B2A7C6265EF0CE2BD7051B7CEF945BABD47EE6D",
+		"1FBD0013C674AA79CB39849527916CE301C66EA7CE8B80682786AD60F98F7E78A19CA69EFF5C57400E3B3A0AD66CE0978214D13BAF4E9AC60752F7B155E2DE4DCE3"},
+}
+
+func rfc6979Key(curve elliptic.Curve, x string) *ecdsa.PrivateKey {
+	var priv = &ecdsa.PrivateKey{D: hexInt(x)}
+	priv.PublicKey.Curve = curve
+	priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(priv.D.Bytes())
+	return priv
+}
+
+func Test_RFC6979_Vectors(t *testing.T) {
+
+	for _, v := range rfc6979Vectors {
+		var priv = rfc6979Key(v.curve, v.x)
+		var digest = doHashAlg([]byte(v.message), v.alg)
+
+		//The generic implementation, as used by ES256K.
+		r, s, err := signRFC6979(priv, digest, HashFunc(v.alg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Cmp(hexInt(v.r)) != 0 || s.Cmp(hexInt(v.s)) != 0 {
+			t.Errorf("%s %q: unexpected signature %X, %X", GetAlgorithmName(v.alg), v.message, r, s)
+		}
+
+		//The one of crypto/ecdsa, used for the NIST curves.
+		r, s, err = EllipticSignDigest(digest, DeterministicSigner(priv), v.alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Cmp(hexInt(v.r)) != 0 || s.Cmp(hexInt(v.s)) != 0 {
+			t.Errorf("%s %q: unexpected signature %X, %X", GetAlgorithmName(v.alg), v.message, r, s)
+		}
+	}
+}
+
+func Test_RFC6979_ES256K(t *testing.T) {
+
+	priv, err := ecdsa.GenerateKey(Secp256k1(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var digest = doHashAlg(testDefaultMessage, ES256K)
+	r1, s1, err := EllipticSignDigest(digest, DeterministicSigner(priv), ES256K)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r2, s2, err := EllipticSignDigest(digest, DeterministicSigner(priv), ES256K)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r1.Cmp(r2) != 0 || s1.Cmp(s2) != 0 {
+		t.Error("The signatures must be equal")
+	}
+
+	if err = EllipticVerifyDigest(digest, &priv.PublicKey, r1, s1, ES256K); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = DeterministicSigner(priv).Sign(nil, digest, crypto.Hash(0)); err == nil {
+		t.Error("A hash is required")
+	}
+}
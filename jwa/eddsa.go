@@ -0,0 +1,43 @@
+package jwa
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+)
+
+//EdDSASign signs the message with an Ed25519 private key: https://tools.ietf.org/html/rfc8037#section-3.1
+//privateKey can be any crypto.Signer with an Ed25519 public key.
+//EdDSA hashes the message on its own, so there is no digest variant.
+func EdDSASign(message []byte, privateKey crypto.PrivateKey) ([]byte, error) {
+
+	//A malformed ed25519.PrivateKey would make Public panic.
+	if priv, ok := privateKey.(ed25519.PrivateKey); ok && len(priv) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidKey
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	if pub, ok := signer.Public().(ed25519.PublicKey); !ok || len(pub) != ed25519.PublicKeySize {
+		return nil, ErrInvalidKey
+	}
+
+	return signer.Sign(rand.Reader, message, crypto.Hash(0))
+}
+
+//EdDSAVerify will return nil if signature is the Ed25519 signature of message with the public key.
+func EdDSAVerify(message, signature []byte, publicKey crypto.PublicKey) error {
+
+	pub, ok := publicKey.(ed25519.PublicKey)
+	if !ok || len(pub) != ed25519.PublicKeySize {
+		return ErrInvalidKey
+	}
+
+	if !ed25519.Verify(pub, message, signature) {
+		return ErrAlteredMessage
+	}
+	return nil
+}
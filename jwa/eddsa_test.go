@@ -0,0 +1,34 @@
+package jwa
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func Test_EdDSA_SignVerify(t *testing.T) {
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := EdDSASign(testDefaultMessage, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = EdDSAVerify(testDefaultMessage, signature, pub); err != nil {
+		t.Fatal(err)
+	}
+
+	signature[0] ^= 1
+	if err = EdDSAVerify(testDefaultMessage, signature, pub); !errors.Is(err, ErrAlteredMessage) {
+		t.Errorf("Expected %s, found %v", ErrAlteredMessage, err)
+	}
+
+	if _, err = EdDSASign(testDefaultMessage, testRSAPrivateKey); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", ErrInvalidKey, err)
+	}
+}
@@ -5,9 +5,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/sha256"
-	"crypto/sha512"
-	"errors"
+	"encoding/asn1"
 	"math/big"
 )
 
@@ -16,28 +14,53 @@ var zero = big.NewInt(0)
 //EllipticSign using ESXXX algorithms.
 func EllipticSign(message []byte, priv crypto.PrivateKey, alg Algorithm) (r, s *big.Int, err error) {
 
-	var pk *ecdsa.PrivateKey
-	var ok bool
-	if pk, ok = priv.(*ecdsa.PrivateKey); !ok {
-		return zero, zero, errors.New(ErrInvalidKey)
+	//An unknown alg produces no hash, but it's rejected by the curve check.
+	return EllipticSignDigest(doHashAlg(message, alg), priv, alg)
+}
+
+//EllipticSignDigest signs a digest of the message computed with HashFunc(alg).
+//priv can be any crypto.Signer with an ECDSA public key, such as a key kept in
+//a HSM or a KMS. Its ASN.1 signature is returned as the r and s integers.
+//The nonce is random unless priv is a DeterministicSigner.
+func EllipticSignDigest(digest []byte, priv crypto.PrivateKey, alg Algorithm) (r, s *big.Int, err error) {
+
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return zero, zero, ErrInvalidKey
+	}
+
+	pub, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return zero, zero, ErrInvalidKey
 	}
 
 	//If the Algorithm doesn't match the curve, abort.
-	if err = curveAndHashMatch(pk.Curve.Params(), alg); err != nil {
+	if err = curveAndHashMatch(pub.Curve.Params(), alg); err != nil {
 		return zero, zero, err
 	}
 
-	hash := doHashAlg(message, alg)
-	if hash == nil {
-		return zero, zero, errors.New(ErrInvalidAlgorithm)
+	der, err := signer.Sign(rand.Reader, digest, HashFunc(alg))
+	if err != nil {
+		return zero, zero, err
 	}
 
-	return ecdsa.Sign(rand.Reader, pk, hash)
+	var sig struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(der, &sig); err != nil || len(rest) != 0 || sig.R == nil || sig.S == nil {
+		return zero, zero, ErrInvalidSource
+	}
+	return sig.R, sig.S, nil
 }
 
 //EllipticVerify for the ESXXX digital signature algorithms. error = nil means Verification correct.
 func EllipticVerify(message []byte, publicKey crypto.PublicKey, r, s *big.Int, alg Algorithm) error {
 
+	//An unknown alg produces no hash, but it's rejected by the curve check.
+	return EllipticVerifyDigest(doHashAlg(message, alg), publicKey, r, s, alg)
+}
+
+//EllipticVerifyDigest is EllipticVerify for a digest of the message computed with HashFunc(alg).
+func EllipticVerifyDigest(digest []byte, publicKey crypto.PublicKey, r, s *big.Int, alg Algorithm) error {
+
 	var err error
 	if pub, ok := publicKey.(*ecdsa.PublicKey); ok {
 
@@ -46,41 +69,17 @@ func EllipticVerify(message []byte, publicKey crypto.PublicKey, r, s *big.Int, a
 			return err
 		}
 
-		hash := doHashAlg(message, alg)
-		if hash == nil {
-			return errors.New(ErrInvalidAlgorithm)
-		}
-
-		if ecdsa.Verify(pub, hash, r, s) {
+		if ecdsa.Verify(pub, digest, r, s) {
 			return nil
 		}
-		return errors.New(ErrAlteredMessage)
+		return ErrAlteredMessage
 
 	}
-	return errors.New(ErrInvalidKey)
+	return ErrInvalidKey
 }
 
 func doHashAlg(message []byte, alg Algorithm) []byte {
-	//	var hash hash.Hash
-	switch alg {
-	case ES256:
-		a := sha256.Sum256(message)
-		return a[:]
-		//hash = sha256.New()
-	case ES384:
-		a := sha512.Sum384(message)
-		return a[:]
-		//hash = sha512.New384()
-	case ES512:
-		a := sha512.Sum512(message)
-		return a[:]
-		//hash = sha512.New()
-	default:
-		return nil
-	}
-
-	//hash.Write(message)
-	//return hash.Sum(nil)
+	return doHash(message, HashFunc(alg))
 }
 
 //Each ESXXX algorithm has a curve assigned. If the key has a Curve with a name different that
@@ -88,26 +87,97 @@ func doHashAlg(message []byte, alg Algorithm) []byte {
 //returns an error with ErrInvalidCurve as message.
 func curveAndHashMatch(curveParams *elliptic.CurveParams, alg Algorithm) error {
 
-	var errText = ""
+	var err error
 	switch alg {
 	case ES256:
 		if curveParams.Name != ECP256Name {
-			errText = ErrInvalidCurve
+			err = ErrInvalidCurve
 		}
 	case ES384:
 		if curveParams.Name != ECP384Name {
-			errText = ErrInvalidCurve
+			err = ErrInvalidCurve
 		}
 	case ES512:
 		if curveParams.Name != ECP521Name {
-			errText = ErrInvalidCurve
+			err = ErrInvalidCurve
+		}
+	case ES256K:
+		if curveParams.Name != ECSecp256k1Name {
+			err = ErrInvalidCurve
 		}
 	default:
-		errText = ErrInvalidAlgorithm
+		err = ErrInvalidAlgorithm
 	}
 
-	if errText == "" {
-		return nil
+	return err
+}
+
+//EncodeEllipticSignature returns the JWS Signature of the ESXXX algorithms: the r and s
+//integers as big-endian octet sequences of the size of the curve order, one after the other.
+//See https://tools.ietf.org/html/rfc7518#section-3.4
+func EncodeEllipticSignature(r, s *big.Int, alg Algorithm) ([]byte, error) {
+
+	var size = octetsLength(alg)
+	if size < 0 {
+		return nil, ErrInvalidAlgorithm
+	}
+
+	if r == nil || s == nil || r.Sign() <= 0 || s.Sign() <= 0 || r.BitLen() > size*8 || s.BitLen() > size*8 {
+		return nil, ErrMalformedSignature
+	}
+
+	var signature = make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	return signature, nil
+}
+
+//DecodeEllipticSignature returns the r and s integers of the JWS Signature of an ESXXX algorithm.
+//The signature must have exactly the size of EncodeEllipticSignature output for alg.
+func DecodeEllipticSignature(signature []byte, alg Algorithm) (r, s *big.Int, err error) {
+
+	var size = octetsLength(alg)
+	if size < 0 {
+		return nil, nil, ErrInvalidAlgorithm
+	}
+
+	if len(signature) != 2*size {
+		return nil, nil, ErrMalformedSignature
+	}
+
+	r = new(big.Int).SetBytes(signature[:size])
+	s = new(big.Int).SetBytes(signature[size:])
+	if r.Sign() == 0 || s.Sign() == 0 {
+		return nil, nil, ErrMalformedSignature
+	}
+	return r, s, nil
+}
+
+//IsLowS reports whether s is at most half the order of curve. Any signature (r, s) has a twin
+//(r, N - s) that is valid too, so the protocols that identify a signature by its bytes only
+//accept the one with the low S, as Bitcoin does: https://github.com/bitcoin/bips/blob/master/bip-0062.mediawiki
+func IsLowS(s *big.Int, curve elliptic.Curve) bool {
+	var half = new(big.Int).Rsh(curve.Params().N, 1)
+	return s.Cmp(half) <= 0
+}
+
+//NormalizeLowS returns s if it's low, or N - s otherwise.
+func NormalizeLowS(s *big.Int, curve elliptic.Curve) *big.Int {
+	if IsLowS(s, curve) {
+		return s
+	}
+	return new(big.Int).Sub(curve.Params().N, s)
+}
+
+func octetsLength(alg Algorithm) int {
+	switch alg {
+	case ES256, ES256K:
+		return ESP256Octets / 2
+	case ES384:
+		return ESP384Octets / 2
+	case ES512:
+		return ESP521Octets / 2
+	default:
+		return -1
 	}
-	return errors.New(errText)
 }
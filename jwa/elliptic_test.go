@@ -4,6 +4,8 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"errors"
+	"math/big"
 	"testing"
 )
 
@@ -82,14 +84,14 @@ func Test_EC256_UsingGreaterCurve(t *testing.T) {
 	r, s, err := EllipticSign(testDefaultMessage, testECPrivateKey, ES256)
 	if err == nil {
 		t.Fatalf("(Sign) nil error, but expecting <%s>", ErrInvalidCurve)
-	} else if err.Error() != ErrInvalidCurve {
+	} else if !errors.Is(err, ErrInvalidCurve) {
 		t.Errorf("Signature failed but it's due to <%s> instead of <%s>", err.Error(), ErrInvalidCurve)
 	}
 
 	//The signature is made out of r and s. So we need them to verify the message has not been altered.
 	if err = EllipticVerify(testDefaultMessage, testECPublicKey, r, s, ES256); err == nil {
 		t.Fatalf("(Verify) nil error, but expecting <%s>", ErrInvalidCurve)
-	} else if err.Error() != ErrInvalidCurve {
+	} else if !errors.Is(err, ErrInvalidCurve) {
 		t.Errorf("Verification failed but it's due to <%s> instead of <%s>", err.Error(), ErrInvalidCurve)
 	}
 
@@ -115,3 +117,111 @@ func Test_EC521_UsingLowerCurve(t *testing.T) {
 	}
 
 }
+
+func Test_EllipticSignature_Codec(t *testing.T) {
+
+	//Leading zeros are padding, trailing zeros are part of the value.
+	var r, s = big.NewInt(0x0100), big.NewInt(1)
+	signature, err := EncodeEllipticSignature(r, s, ES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(signature) != ESP256Octets || signature[30] != 1 || signature[31] != 0 || signature[63] != 1 {
+		t.Errorf("Unexpected encoding: %x", signature)
+	}
+
+	nr, ns, err := DecodeEllipticSignature(signature, ES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nr.Cmp(r) != 0 || ns.Cmp(s) != 0 {
+		t.Errorf("Unexpected r, s: %x, %x", nr, ns)
+	}
+
+	for _, size := range []int{0, 1, ESP256Octets - 1, ESP256Octets + 1, ESP384Octets} {
+		if _, _, err = DecodeEllipticSignature(make([]byte, size), ES256); !errors.Is(err, ErrMalformedSignature) {
+			t.Errorf("%d: expected %s, found %v", size, ErrMalformedSignature, err)
+		}
+	}
+
+	//Zero values and values that don't fit.
+	var huge = new(big.Int).Lsh(big.NewInt(1), 256)
+	for _, v := range [][2]*big.Int{{zero, s}, {r, zero}, {huge, s}, {r, huge}, {nil, s}} {
+		if _, err = EncodeEllipticSignature(v[0], v[1], ES256); !errors.Is(err, ErrMalformedSignature) {
+			t.Errorf("Expected %s, found %v", ErrMalformedSignature, err)
+		}
+	}
+
+	if _, _, err = DecodeEllipticSignature(make([]byte, ESP256Octets), ES256); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("Expected %s, found %v", ErrMalformedSignature, err)
+	}
+
+	if _, _, err = DecodeEllipticSignature(signature, RS256); !errors.Is(err, ErrInvalidAlgorithm) {
+		t.Errorf("Expected %s, found %v", ErrInvalidAlgorithm, err)
+	}
+}
+
+func Test_EllipticSignature_LowS(t *testing.T) {
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n = priv.Curve.Params().N
+	var half = new(big.Int).Rsh(n, 1)
+	if !IsLowS(half, priv.Curve) || IsLowS(new(big.Int).Add(half, big.NewInt(1)), priv.Curve) {
+		t.Error("Wrong boundary")
+	}
+
+	r, s, err := EllipticSign(testDefaultMessage, priv, ES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Both twins are valid, and only one of them is low.
+	var twin = new(big.Int).Sub(n, s)
+	if IsLowS(s, priv.Curve) == IsLowS(twin, priv.Curve) {
+		t.Error("Only one of the twins must be low")
+	}
+
+	for _, v := range []*big.Int{s, twin} {
+		var low = NormalizeLowS(v, priv.Curve)
+		if !IsLowS(low, priv.Curve) {
+			t.Errorf("Not normalized: %x", low)
+		}
+		if err = EllipticVerify(testDefaultMessage, &priv.PublicKey, r, low, ES256); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+//Malformed signatures must be rejected without panics, and the accepted ones must be canonical.
+func FuzzDecodeEllipticSignature(f *testing.F) {
+
+	f.Add([]byte{}, uint8(ES256))
+	f.Add([]byte{0}, uint8(ES384))
+	f.Add(make([]byte, ESP256Octets), uint8(ES256))
+	f.Add(append(make([]byte, ESP521Octets-1), 1), uint8(ES512))
+	f.Add(append([]byte{1}, make([]byte, ESP384Octets-1)...), uint8(ES384))
+	f.Add(make([]byte, 200), uint8(ES256K))
+
+	f.Fuzz(func(t *testing.T, signature []byte, code uint8) {
+
+		var alg = Algorithm(code)
+		r, s, err := DecodeEllipticSignature(signature, alg)
+		if err != nil {
+			return
+		}
+
+		encoded, err := EncodeEllipticSignature(r, s, alg)
+		if err != nil {
+			t.Fatalf("Decoded but not encoded: %v", err)
+		}
+
+		if string(encoded) != string(signature) {
+			t.Fatalf("Not canonical: %x, %x", signature, encoded)
+		}
+	})
+}
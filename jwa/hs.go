@@ -1,28 +1,15 @@
 package jwa
 
 import (
-	"bytes"
-	"crypto"
 	"crypto/hmac"
-	"crypto/sha256"
-	"crypto/sha512"
 	"hash"
 )
 
 //HMACSignature supports the hashing algorithms SHA-256/384/512
 func HMACSignature(message []byte, key []byte, alg Algorithm) []byte {
 
-	var hashAlg = translateAlgorithm(alg)
-
-	var h hash.Hash
-	switch hashAlg {
-	case crypto.SHA256:
-		h = hmac.New(sha256.New, key)
-	case crypto.SHA384:
-		h = hmac.New(sha512.New384, key)
-	case crypto.SHA512:
-		h = hmac.New(sha512.New, key)
-	default:
+	h, err := HMACHash(key, alg)
+	if err != nil {
 		return nil
 	}
 
@@ -38,5 +25,17 @@ func HMACVerify(message, signature, key []byte, alg Algorithm) bool {
 		return false
 	}
 
-	return bytes.Equal(newsign, signature)
+	return hmac.Equal(newsign, signature)
+}
+
+//HMACHash returns a hash.Hash computing the MAC of alg with key, so the message
+//can be written incrementally. alg must be HS256, HS384 or HS512.
+func HMACHash(key []byte, alg Algorithm) (hash.Hash, error) {
+
+	switch alg {
+	case HS256, HS384, HS512:
+		return hmac.New(HashFunc(alg).New, key), nil
+	default:
+		return nil, ErrInvalidAlgorithm
+	}
 }
@@ -3,6 +3,7 @@ package jwa
 import (
 	"bytes"
 	"crypto/sha256"
+	"errors"
 	"testing"
 )
 
@@ -30,6 +31,28 @@ func Test_HMAC512_SignVerify(t *testing.T) {
 	}
 }
 
+func Test_HMACHash_Algorithms(t *testing.T) {
+
+	//Only the HSXXX algorithms are MACs, whatever their hash.
+	for _, alg := range []Algorithm{RS256, PS384, ES512, EdDSA, UNSUP, Algorithm(200)} {
+		if h, err := HMACHash(testHMACKey, alg); h != nil || !errors.Is(err, ErrInvalidAlgorithm) {
+			t.Errorf("%d: expected %s, found %v", alg, ErrInvalidAlgorithm, err)
+		}
+		if HMACSignature(testDefaultMessage, testHMACKey, alg) != nil || HMACVerify(testDefaultMessage, nil, testHMACKey, alg) {
+			t.Errorf("%d: unexpected MAC", alg)
+		}
+	}
+
+	h, err := HMACHash(testHMACKey, HS384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write(testDefaultMessage)
+	if !bytes.Equal(h.Sum(nil), HMACSignature(testDefaultMessage, testHMACKey, HS384)) {
+		t.Error("The incremental MAC differs")
+	}
+}
+
 //According to https://www.di-mgt.com.au/sha_testvectors.html
 //an empty string possesses a valid hash. This means that an
 //empty message can be hashed and signed.
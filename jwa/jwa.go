@@ -1,6 +1,7 @@
 package jwa
 
 import (
+	"errors"
 	"math/rand"
 )
 
@@ -28,6 +29,22 @@ const (
 	ES384
 	//ES512 is the code for elliptic curve P-521 usin SHA-512
 	ES512
+	//PS256 is the code for RSASSA-PSS using SHA-256 and MGF1 with SHA-256
+	PS256
+	//PS384 is the code for RSASSA-PSS using SHA-384 and MGF1 with SHA-384
+	PS384
+	//PS512 is the code for RSASSA-PSS using SHA-512 and MGF1 with SHA-512
+	PS512
+	//EdDSA is the code for the Edwards-curve Digital Signature Algorithm with Ed25519
+	EdDSA
+	//MLDSA44 is the code for the ML-DSA-44 post-quantum signature of FIPS 204
+	MLDSA44
+	//MLDSA65 is the code for the ML-DSA-65 post-quantum signature of FIPS 204
+	MLDSA65
+	//MLDSA87 is the code for the ML-DSA-87 post-quantum signature of FIPS 204
+	MLDSA87
+	//ES256K is the code for elliptic curve secp256k1 using SHA-256
+	ES256K
 )
 
 const (
@@ -44,12 +61,31 @@ const (
 	//RS512Name signature with RSASSA-PKCS1-v1_5 using SHA-512
 	RS512Name = `RS512`
 
+	//PS256Name signature with RSASSA-PSS using SHA-256 and MGF1 with SHA-256
+	PS256Name = `PS256`
+	//PS384Name signature with RSASSA-PSS using SHA-384 and MGF1 with SHA-384
+	PS384Name = `PS384`
+	//PS512Name signature with RSASSA-PSS using SHA-512 and MGF1 with SHA-512
+	PS512Name = `PS512`
+
 	//ES256Name signature with the elliptic curve P-256 using SHA-256
 	ES256Name = `ES256`
 	//ES384Name signature with the elliptic curve P-384 using SHA-384
 	ES384Name = `ES384`
 	//ES512Name signature with the elliptic curve P-521 using SHA-512
 	ES512Name = `ES512`
+	//ES256KName signature with the elliptic curve secp256k1 using SHA-256: https://tools.ietf.org/html/rfc8812#section-3.2
+	ES256KName = `ES256K`
+
+	//EdDSAName signature with the Edwards-curve Digital Signature Algorithm: https://tools.ietf.org/html/rfc8037#section-3.1
+	EdDSAName = `EdDSA`
+
+	//MLDSA44Name signature with ML-DSA-44: https://datatracker.ietf.org/doc/draft-ietf-cose-dilithium/
+	MLDSA44Name = `ML-DSA-44`
+	//MLDSA65Name signature with ML-DSA-65: https://datatracker.ietf.org/doc/draft-ietf-cose-dilithium/
+	MLDSA65Name = `ML-DSA-65`
+	//MLDSA87Name signature with ML-DSA-87: https://datatracker.ietf.org/doc/draft-ietf-cose-dilithium/
+	MLDSA87Name = `ML-DSA-87`
 
 	//ESP256Octets is the required space for signature serialization
 	ESP256Octets = 64
@@ -64,30 +100,36 @@ const (
 	ECP384Name = `P-384`
 	//ECP521Name identifier for Elliptic Curve P-521
 	ECP521Name = `P-521`
+	//ECSecp256k1Name identifier for the Elliptic Curve secp256k1: https://tools.ietf.org/html/rfc8812#section-3.1
+	ECSecp256k1Name = `secp256k1`
+	//Ed25519Name identifier for the Edwards curve Ed25519
+	Ed25519Name = `Ed25519`
 )
 
-const (
+var (
 	//ErrIllegalIndex means that the given offset is out of bounds.
-	ErrIllegalIndex = `the writting index is illegal`
+	ErrIllegalIndex = errors.New("the writting index is illegal")
 	//ErrInvalidWhence is not SeekStart, SeekCurrent nor SeekEnd.
-	ErrInvalidWhence = `reference is not SeekStart, SeekCurrent nor SeekEnd`
+	ErrInvalidWhence = errors.New("reference is not SeekStart, SeekCurrent nor SeekEnd")
 	//ErrNoSpace the writer cannot fit the requested data.
-	ErrNoSpace = `not enough space`
+	ErrNoSpace = errors.New("not enough space")
 	//ErrInvalidSource means that the input data is not valid
-	ErrInvalidSource = `invalid source`
+	ErrInvalidSource = errors.New("invalid source")
 	//ErrInvalidInput means that the arguments for this function were invalid
-	ErrInvalidInput = `invalid input`
+	ErrInvalidInput = errors.New("invalid input")
 	//ErrInvalidAlgorithm means that the algorithm provided is not supported or recognized.
-	ErrInvalidAlgorithm = `invalid algorithm`
+	ErrInvalidAlgorithm = errors.New("invalid algorithm")
 	//ErrInvalidKey means that the given public or private key is invalid
-	ErrInvalidKey = `invalid key`
+	ErrInvalidKey = errors.New("invalid key")
 	//ErrAlteredMessage means that the verification failed because the message was altered
-	ErrAlteredMessage = `altered message on verification`
+	ErrAlteredMessage = errors.New("altered message on verification")
 	//ErrInvalidCurve means that the given curve is not appropriate in this context.
 	//It could be because the curve is not recognized or because a different one was expected.
-	ErrInvalidCurve = `invalid curve`
+	ErrInvalidCurve = errors.New("invalid curve")
 	//ErrInvalidKeyLength means that the used key has an invalid size.
-	ErrInvalidKeyLength = `invalid key length`
+	ErrInvalidKeyLength = errors.New("invalid key length")
+	//ErrMalformedSignature means that the signature doesn't have the encoding or the size required by the algorithm.
+	ErrMalformedSignature = errors.New("malformed signature")
 )
 
 const (
@@ -0,0 +1,71 @@
+package jwa
+
+import (
+	"crypto"
+	"crypto/mldsa"
+	"crypto/rand"
+)
+
+//MLDSASign signs the message with a ML-DSA private key of the parameter set of alg.
+//privateKey can be any crypto.Signer with a *mldsa.PublicKey. The signature has an
+//empty context, as required by https://datatracker.ietf.org/doc/draft-ietf-cose-dilithium/
+//ML-DSA signs the whole message, so there is no digest variant.
+func MLDSASign(message []byte, privateKey crypto.PrivateKey, alg Algorithm) ([]byte, error) {
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	if err := mldsaCheckKey(signer.Public(), alg); err != nil {
+		return nil, err
+	}
+
+	return signer.Sign(rand.Reader, message, crypto.Hash(0))
+}
+
+//MLDSAVerify will return nil if signature is the ML-DSA signature of message with the public key.
+func MLDSAVerify(message, signature []byte, publicKey crypto.PublicKey, alg Algorithm) error {
+
+	if err := mldsaCheckKey(publicKey, alg); err != nil {
+		return err
+	}
+
+	if mldsa.Verify(publicKey.(*mldsa.PublicKey), message, signature, nil) != nil {
+		return ErrAlteredMessage
+	}
+	return nil
+}
+
+//MLDSAParameters returns the FIPS 204 parameter set of alg.
+func MLDSAParameters(alg Algorithm) (mldsa.Parameters, error) {
+	switch alg {
+	case MLDSA44:
+		return mldsa.MLDSA44(), nil
+	case MLDSA65:
+		return mldsa.MLDSA65(), nil
+	case MLDSA87:
+		return mldsa.MLDSA87(), nil
+	default:
+		return mldsa.Parameters{}, ErrInvalidAlgorithm
+	}
+}
+
+//mldsaCheckKey ensures that the public key is of the parameter set of alg.
+func mldsaCheckKey(publicKey crypto.PublicKey, alg Algorithm) error {
+
+	params, err := MLDSAParameters(alg)
+	if err != nil {
+		return err
+	}
+
+	pub, ok := publicKey.(*mldsa.PublicKey)
+	if !ok || pub == nil {
+		return ErrInvalidKey
+	}
+
+	if pub.Parameters() != params {
+		return ErrInvalidKeyLength
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package jwa
+
+import (
+	"crypto/mldsa"
+	"errors"
+	"testing"
+)
+
+func Test_MLDSA_SignVerify(t *testing.T) {
+
+	for _, alg := range []Algorithm{MLDSA44, MLDSA65, MLDSA87} {
+		params, err := MLDSAParameters(alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		priv, err := mldsa.GenerateKey(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signature, err := MLDSASign(testDefaultMessage, priv, alg)
+		if err != nil {
+			t.Fatalf("%s: %v", GetAlgorithmName(alg), err)
+		}
+
+		if len(signature) != params.SignatureSize() {
+			t.Errorf("%s: unexpected signature size %d", GetAlgorithmName(alg), len(signature))
+		}
+
+		if err = MLDSAVerify(testDefaultMessage, signature, priv.PublicKey(), alg); err != nil {
+			t.Errorf("%s: %v", GetAlgorithmName(alg), err)
+		}
+
+		signature[0] ^= 1
+		if err = MLDSAVerify(testDefaultMessage, signature, priv.PublicKey(), alg); !errors.Is(err, ErrAlteredMessage) {
+			t.Errorf("Expected %s, found %v", ErrAlteredMessage, err)
+		}
+	}
+}
+
+func Test_MLDSA_WrongKey(t *testing.T) {
+
+	priv, err := mldsa.GenerateKey(mldsa.MLDSA44())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//The parameter set must be the one of the algorithm.
+	if _, err = MLDSASign(testDefaultMessage, priv, MLDSA65); !errors.Is(err, ErrInvalidKeyLength) {
+		t.Errorf("Expected %s, found %v", ErrInvalidKeyLength, err)
+	}
+
+	if _, err = MLDSASign(testDefaultMessage, testRSAPrivateKey, MLDSA44); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", ErrInvalidKey, err)
+	}
+
+	if _, err = MLDSASign(testDefaultMessage, priv, ES256); !errors.Is(err, ErrInvalidAlgorithm) {
+		t.Errorf("Expected %s, found %v", ErrInvalidAlgorithm, err)
+	}
+}
@@ -3,7 +3,6 @@ package jwa
 import (
 	"bytes"
 	"encoding/binary"
-	"errors"
 	"io"
 	"math/big"
 )
@@ -23,7 +22,7 @@ func NewOctetWriter(ESIdentifier Algorithm) (ESSignatureWriter, error) {
 	} else if ESIdentifier == ES512 {
 		return ESSignatureWriter{Data: make([]byte, ESP521Octets, ESP521Octets), Index: 0}, nil
 	}
-	return ESSignatureWriter{}, errors.New(ErrInvalidAlgorithm)
+	return ESSignatureWriter{}, ErrInvalidAlgorithm
 }
 
 //RemainingSpace is the number of bytes that can be written into this writer.
@@ -39,11 +38,11 @@ func (o *ESSignatureWriter) Write(p []byte) (n int, err error) {
 	var toWrite = space
 
 	if p == nil {
-		return 0, errors.New(ErrInvalidSource)
+		return 0, ErrInvalidSource
 	}
 
 	if space == 0 {
-		return 0, errors.New(ErrNoSpace)
+		return 0, ErrNoSpace
 	}
 
 	if int64(len(p)) < space {
@@ -72,7 +71,7 @@ Seeking to any positive offset is legal, and will be set to
 func (o *ESSignatureWriter) Seek(offset int64, whence int) (int64, error) {
 
 	if offset < 0 {
-		return int64(o.Index), errors.New(ErrIllegalIndex)
+		return int64(o.Index), ErrIllegalIndex
 	}
 
 	var position int64
@@ -83,11 +82,11 @@ func (o *ESSignatureWriter) Seek(offset int64, whence int) (int64, error) {
 	} else if whence == io.SeekEnd {
 		position = int64(cap(o.Data)) - offset
 	} else {
-		return o.Index, errors.New(ErrInvalidWhence)
+		return o.Index, ErrInvalidWhence
 	}
 
 	if position < 0 || position > int64(cap(o.Data)) {
-		return o.Index, errors.New(ErrIllegalIndex)
+		return o.Index, ErrIllegalIndex
 	}
 
 	o.Index = position
@@ -98,7 +97,7 @@ func (o *ESSignatureWriter) Seek(offset int64, whence int) (int64, error) {
 //As the signature is made out of two big integers, and the whole capacity must be filled.
 func (o *ESSignatureWriter) WriteNumber(x *big.Int) error {
 	if x == nil {
-		return errors.New(ErrInvalidInput)
+		return ErrInvalidInput
 	}
 	return binary.Write(o, binary.BigEndian, fillBytes(x.Bytes(), cap(o.Data)/2))
 }
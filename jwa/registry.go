@@ -0,0 +1,305 @@
+package jwa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"hash"
+	"sync"
+)
+
+//Implementation describes a JWS signature algorithm, so that it can be used by its
+//"alg" Header Parameter Value without changes to this package nor to jws.
+type Implementation struct {
+	//Name is the "alg" Header Parameter Value.
+	Name string
+	//KeyType is the "kty" of the JWK keys used by the algorithm, such as EC, RSA, oct or OKP.
+	KeyType string
+	//Hash is the hash function of the algorithm, or zero if it doesn't use one.
+	Hash crypto.Hash
+
+	//CheckKey returns an error if key, private or public, cannot be used with the algorithm.
+	//If nil, every key is accepted.
+	CheckKey func(key interface{}) error
+	//Sign returns the signature, or the MAC, of message.
+	Sign func(message []byte, key crypto.PrivateKey) ([]byte, error)
+	//Verify returns nil if signature is valid for message.
+	Verify func(message, signature []byte, key crypto.PublicKey) error
+
+	//NewHash, SignDigest and VerifyDigest are optional, and are needed to sign a message
+	//that is written incrementally, as jws.SignStream does. NewHash receives the key,
+	//so MACs can be keyed. If they are given, Sign and Verify can be left nil.
+	NewHash      func(key interface{}) (hash.Hash, error)
+	SignDigest   func(digest []byte, key crypto.PrivateKey) ([]byte, error)
+	VerifyDigest func(digest, signature []byte, key crypto.PublicKey) error
+}
+
+var (
+	registryMu      sync.RWMutex
+	implementations = map[Algorithm]Implementation{}
+	algorithmCodes  = map[string]Algorithm{}
+	nextAlgorithm   = ES256K + 1
+)
+
+//RegisterAlgorithm makes impl available under impl.Name and returns the code of the new algorithm.
+//It's meant to be called at init time. The names already registered, such as the ones
+//defined by JWA, are rejected.
+func RegisterAlgorithm(impl Implementation) (Algorithm, error) {
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	var alg = nextAlgorithm
+	if err := register(alg, impl); err != nil {
+		return UNSUP, err
+	}
+
+	nextAlgorithm++
+	return alg, nil
+}
+
+//GetImplementation returns the Implementation of alg.
+func GetImplementation(alg Algorithm) (Implementation, error) {
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	impl, ok := implementations[alg]
+	if !ok {
+		return Implementation{}, ErrInvalidAlgorithm
+	}
+	return impl, nil
+}
+
+//register must be called with registryMu held.
+func register(alg Algorithm, impl Implementation) error {
+
+	if impl.Name == "" {
+		return ErrInvalidInput
+	}
+
+	if _, ok := algorithmCodes[impl.Name]; ok {
+		return ErrInvalidAlgorithm
+	}
+
+	//The digest functions go together, or they couldn't be used.
+	var digests = 0
+	for _, given := range []bool{impl.NewHash != nil, impl.SignDigest != nil, impl.VerifyDigest != nil} {
+		if given {
+			digests++
+		}
+	}
+	if digests != 0 && digests != 3 {
+		return ErrInvalidInput
+	}
+
+	if impl.Sign == nil && digests == 3 {
+		impl.Sign = signWithDigest(impl.NewHash, impl.SignDigest)
+	}
+	if impl.Verify == nil && digests == 3 {
+		impl.Verify = verifyWithDigest(impl.NewHash, impl.VerifyDigest)
+	}
+
+	if impl.Sign == nil || impl.Verify == nil {
+		return ErrInvalidInput
+	}
+
+	implementations[alg] = impl
+	algorithmCodes[impl.Name] = alg
+	return nil
+}
+
+func signWithDigest(newHash func(interface{}) (hash.Hash, error),
+	signDigest func([]byte, crypto.PrivateKey) ([]byte, error)) func([]byte, crypto.PrivateKey) ([]byte, error) {
+
+	return func(message []byte, key crypto.PrivateKey) ([]byte, error) {
+		h, err := newHash(key)
+		if err != nil {
+			return nil, err
+		}
+		h.Write(message)
+		return signDigest(h.Sum(nil), key)
+	}
+}
+
+func verifyWithDigest(newHash func(interface{}) (hash.Hash, error),
+	verifyDigest func([]byte, []byte, crypto.PublicKey) error) func([]byte, []byte, crypto.PublicKey) error {
+
+	return func(message, signature []byte, key crypto.PublicKey) error {
+		h, err := newHash(key)
+		if err != nil {
+			return err
+		}
+		h.Write(message)
+		return verifyDigest(h.Sum(nil), signature, key)
+	}
+}
+
+func init() {
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	var builtins = []Implementation{
+		HS256:   hmacImplementation(HS256Name, crypto.SHA256),
+		HS384:   hmacImplementation(HS384Name, crypto.SHA384),
+		HS512:   hmacImplementation(HS512Name, crypto.SHA512),
+		RS256:   rsaImplementation(RS256, RS256Name, crypto.SHA256),
+		RS384:   rsaImplementation(RS384, RS384Name, crypto.SHA384),
+		RS512:   rsaImplementation(RS512, RS512Name, crypto.SHA512),
+		ES256:   ellipticImplementation(ES256, ES256Name, crypto.SHA256),
+		ES384:   ellipticImplementation(ES384, ES384Name, crypto.SHA384),
+		ES512:   ellipticImplementation(ES512, ES512Name, crypto.SHA512),
+		PS256:   rsaImplementation(PS256, PS256Name, crypto.SHA256),
+		PS384:   rsaImplementation(PS384, PS384Name, crypto.SHA384),
+		PS512:   rsaImplementation(PS512, PS512Name, crypto.SHA512),
+		EdDSA:   eddsaImplementation(),
+		MLDSA44: mldsaImplementation(MLDSA44, MLDSA44Name),
+		MLDSA65: mldsaImplementation(MLDSA65, MLDSA65Name),
+		MLDSA87: mldsaImplementation(MLDSA87, MLDSA87Name),
+		ES256K:  ellipticImplementation(ES256K, ES256KName, crypto.SHA256),
+	}
+
+	for alg, impl := range builtins {
+		if Algorithm(alg) == UNSUP {
+			continue
+		}
+		if err := register(Algorithm(alg), impl); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func hmacImplementation(name string, h crypto.Hash) Implementation {
+	return Implementation{
+		Name:    name,
+		KeyType: `oct`,
+		Hash:    h,
+		CheckKey: func(key interface{}) error {
+			//MACs only take the shared secret, never an asymmetric key.
+			if k, ok := key.([]byte); ok && len(k) > 0 {
+				return nil
+			}
+			return ErrInvalidAlgorithm
+		},
+		NewHash: func(key interface{}) (hash.Hash, error) {
+			k, ok := key.([]byte)
+			if !ok || len(k) == 0 {
+				return nil, ErrInvalidKey
+			}
+			return hmac.New(h.New, k), nil
+		},
+		SignDigest: func(digest []byte, key crypto.PrivateKey) ([]byte, error) {
+			return digest, nil
+		},
+		VerifyDigest: func(digest, signature []byte, key crypto.PublicKey) error {
+			if !hmac.Equal(digest, signature) {
+				return ErrAlteredMessage
+			}
+			return nil
+		},
+	}
+}
+
+func rsaImplementation(alg Algorithm, name string, h crypto.Hash) Implementation {
+	return Implementation{
+		Name:    name,
+		KeyType: `RSA`,
+		Hash:    h,
+		CheckKey: func(key interface{}) error {
+			if _, ok := publicKeyOf(key).(*rsa.PublicKey); ok {
+				return nil
+			}
+			return ErrInvalidKey
+		},
+		NewHash: func(key interface{}) (hash.Hash, error) {
+			return h.New(), nil
+		},
+		SignDigest: func(digest []byte, key crypto.PrivateKey) ([]byte, error) {
+			return RSASignDigest(digest, key, alg)
+		},
+		VerifyDigest: func(digest, signature []byte, key crypto.PublicKey) error {
+			return RSAVerifyDigest(digest, signature, key, alg)
+		},
+	}
+}
+
+func ellipticImplementation(alg Algorithm, name string, h crypto.Hash) Implementation {
+	return Implementation{
+		Name:    name,
+		KeyType: `EC`,
+		Hash:    h,
+		CheckKey: func(key interface{}) error {
+			if _, ok := publicKeyOf(key).(*ecdsa.PublicKey); ok {
+				return nil
+			}
+			return ErrInvalidKey
+		},
+		NewHash: func(key interface{}) (hash.Hash, error) {
+			return h.New(), nil
+		},
+		SignDigest: func(digest []byte, key crypto.PrivateKey) ([]byte, error) {
+			r, s, err := EllipticSignDigest(digest, key, alg)
+			if err != nil {
+				return nil, err
+			}
+			return EncodeEllipticSignature(r, s, alg)
+		},
+		VerifyDigest: func(digest, signature []byte, key crypto.PublicKey) error {
+			r, s, err := DecodeEllipticSignature(signature, alg)
+			if err != nil {
+				return err
+			}
+			return EllipticVerifyDigest(digest, key, r, s, alg)
+		},
+	}
+}
+
+//EdDSA signs the whole message, so it cannot be used with a digest.
+func eddsaImplementation() Implementation {
+	return Implementation{
+		Name:    EdDSAName,
+		KeyType: `OKP`,
+		CheckKey: func(key interface{}) error {
+			if _, ok := publicKeyOf(key).(ed25519.PublicKey); ok {
+				return nil
+			}
+			return ErrInvalidKey
+		},
+		Sign:   EdDSASign,
+		Verify: EdDSAVerify,
+	}
+}
+
+//ML-DSA signs the whole message, so it cannot be used with a digest either.
+func mldsaImplementation(alg Algorithm, name string) Implementation {
+	return Implementation{
+		Name:    name,
+		KeyType: `AKP`,
+		CheckKey: func(key interface{}) error {
+			return mldsaCheckKey(publicKeyOf(key), alg)
+		},
+		Sign: func(message []byte, key crypto.PrivateKey) ([]byte, error) {
+			return MLDSASign(message, key, alg)
+		},
+		Verify: func(message, signature []byte, key crypto.PublicKey) error {
+			return MLDSAVerify(message, signature, key, alg)
+		},
+	}
+}
+
+//publicKeyOf returns the public key of a private key or crypto.Signer, and key itself otherwise.
+func publicKeyOf(key interface{}) interface{} {
+
+	//A malformed ed25519.PrivateKey would make Public panic.
+	if priv, ok := key.(ed25519.PrivateKey); ok && len(priv) != ed25519.PrivateKeySize {
+		return nil
+	}
+
+	if signer, ok := key.(crypto.Signer); ok {
+		return signer.Public()
+	}
+	return key
+}
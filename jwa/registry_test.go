@@ -0,0 +1,140 @@
+package jwa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"errors"
+	"hash"
+	"testing"
+)
+
+//A keyed SHA-1 MAC, only to exercise the registry. It must never be used for real.
+var testHS1, testHS1Err = RegisterAlgorithm(Implementation{
+	Name:    "test-HS1",
+	KeyType: `oct`,
+	Hash:    crypto.SHA1,
+	NewHash: func(key interface{}) (hash.Hash, error) {
+		k, ok := key.([]byte)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+		h := sha1.New()
+		h.Write(k)
+		return h, nil
+	},
+	SignDigest: func(digest []byte, key crypto.PrivateKey) ([]byte, error) {
+		return digest, nil
+	},
+	VerifyDigest: func(digest, signature []byte, key crypto.PublicKey) error {
+		if string(digest) != string(signature) {
+			return ErrAlteredMessage
+		}
+		return nil
+	},
+})
+
+func Test_Registry_Builtins(t *testing.T) {
+
+	for alg := HS256; alg <= EdDSA; alg++ {
+		var name = GetAlgorithmName(alg)
+		if name == "" || AlgorithmFromName(name) != alg {
+			t.Errorf("%d: not registered as %q", alg, name)
+		}
+	}
+
+	if GetAlgorithmName(UNSUP) != "" || AlgorithmFromName("none") != UNSUP {
+		t.Error("Unexpected algorithm")
+	}
+
+	if HashFunc(PS384) != crypto.SHA384 || HashFunc(EdDSA) != 0 {
+		t.Error("Unexpected hash")
+	}
+}
+
+func Test_Registry_Register(t *testing.T) {
+
+	if testHS1Err != nil {
+		t.Fatal(testHS1Err)
+	}
+
+	if testHS1 <= EdDSA || AlgorithmFromName("test-HS1") != testHS1 || HashFunc(testHS1) != crypto.SHA1 {
+		t.Errorf("Unexpected registration: %d", testHS1)
+	}
+
+	impl, err := GetImplementation(testHS1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Sign and Verify are made out of the digest functions.
+	sig, err := impl.Sign(testDefaultMessage, testHMACKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = impl.Verify(testDefaultMessage, sig, testHMACKey); err != nil {
+		t.Error(err)
+	}
+
+	if err = impl.Verify([]byte("altered"), sig, testHMACKey); !errors.Is(err, ErrAlteredMessage) {
+		t.Errorf("Expected %s, found %v", ErrAlteredMessage, err)
+	}
+}
+
+func Test_Registry_Rejected(t *testing.T) {
+
+	var sign = func(message []byte, key crypto.PrivateKey) ([]byte, error) { return nil, nil }
+	var verify = func(message, signature []byte, key crypto.PublicKey) error { return nil }
+
+	var cases = []struct {
+		impl Implementation
+		err  error
+	}{
+		{Implementation{Name: ES256Name, Sign: sign, Verify: verify}, ErrInvalidAlgorithm},
+		{Implementation{Name: "test-HS1", Sign: sign, Verify: verify}, ErrInvalidAlgorithm},
+		{Implementation{Sign: sign, Verify: verify}, ErrInvalidInput},
+		{Implementation{Name: "test-nosign", Verify: verify}, ErrInvalidInput},
+		{Implementation{Name: "test-partial", Sign: sign, Verify: verify, SignDigest: sign}, ErrInvalidInput},
+	}
+
+	for i, c := range cases {
+		if alg, err := RegisterAlgorithm(c.impl); !errors.Is(err, c.err) || alg != UNSUP {
+			t.Errorf("%d: expected %s, found %v", i, c.err, err)
+		}
+	}
+}
+
+//The ESXXX implementations receive signatures from the tokens, so any length must be refused without panics.
+func Test_Registry_EllipticSignatureLength(t *testing.T) {
+
+	var curves = map[Algorithm]elliptic.Curve{ES256: elliptic.P256(), ES384: elliptic.P384(), ES512: elliptic.P521()}
+	for alg, curve := range curves {
+		priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		impl, err := GetImplementation(alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sig, err := impl.Sign(testDefaultMessage, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = impl.Verify(testDefaultMessage, sig, &priv.PublicKey); err != nil {
+			t.Error(err)
+		}
+
+		//Truncated, extended, and stripped of the last byte when it's zero, as the old codec did.
+		for _, bad := range [][]byte{nil, sig[:1], sig[:len(sig)/2], sig[:len(sig)-1], append(sig[:len(sig):len(sig)], 0)} {
+			if err = impl.Verify(testDefaultMessage, bad, &priv.PublicKey); !errors.Is(err, ErrMalformedSignature) {
+				t.Errorf("%s %d: expected %s, found %v", impl.Name, len(bad), ErrMalformedSignature, err)
+			}
+		}
+	}
+}
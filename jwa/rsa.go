@@ -6,71 +6,101 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/sha512"
-	"errors"
 	"hash"
 )
 
 //RSASign signature using the hashing algorithm hashAlg with the given private key.
+//Both RSASSA-PKCS1-v1_5 (RSXXX) and RSASSA-PSS (PSXXX) algorithms are supported.
 func RSASign(message []byte, privateKey crypto.PrivateKey, alg Algorithm) ([]byte, error) {
+
+	var hash = doHash(message, HashFunc(alg))
+	if hash == nil {
+		return nil, ErrInvalidAlgorithm
+	}
+
+	return RSASignDigest(hash, privateKey, alg)
+}
+
+//RSASignDigest signs a digest of the message computed with HashFunc(alg).
+//privateKey can be any crypto.Signer with a RSA public key, such as a key kept
+//in a HSM or a KMS.
+func RSASignDigest(digest []byte, privateKey crypto.PrivateKey, alg Algorithm) ([]byte, error) {
 	var err error
-	priv, ok := privateKey.(*rsa.PrivateKey)
+	signer, ok := privateKey.(crypto.Signer)
 	if !ok {
-		return nil, errors.New(ErrInvalidKey)
+		return nil, ErrInvalidKey
 	}
 
-	if err = rsaCheckKeyLen(priv); err != nil {
+	if err = rsaCheckKeyLen(signer.Public()); err != nil {
 		return nil, err
 	}
 
-	var hashAlg = translateAlgorithm(alg)
-
-	var hash = doHash(message, hashAlg)
-	if hash == nil {
-		return nil, errors.New(ErrInvalidAlgorithm)
+	var hashAlg = HashFunc(alg)
+	if hashAlg == 0 || len(digest) != hashAlg.Size() {
+		return nil, ErrInvalidAlgorithm
 	}
 
-	return rsa.SignPKCS1v15(rand.Reader, priv, hashAlg, hash)
-
+	switch alg {
+	case RS256, RS384, RS512:
+		return signer.Sign(rand.Reader, digest, hashAlg)
+	case PS256, PS384, PS512:
+		return signer.Sign(rand.Reader, digest, &rsa.PSSOptions{SaltLength: pssOptions.SaltLength, Hash: hashAlg})
+	default:
+		return nil, ErrInvalidAlgorithm
+	}
 }
 
 //RSAVerify will return nil if the message with the public key with the hash algorithm generates the given signature.
 func RSAVerify(message, signature []byte, publicKey crypto.PublicKey, alg Algorithm) error {
 
-	var hash = translateAlgorithm(alg)
+	var hashed = doHash(message, HashFunc(alg))
+	if hashed == nil {
+		return ErrInvalidAlgorithm
+	}
+
+	return RSAVerifyDigest(hashed, signature, publicKey, alg)
+}
+
+//RSAVerifyDigest is RSAVerify for a digest of the message computed with HashFunc(alg).
+func RSAVerifyDigest(digest, signature []byte, publicKey crypto.PublicKey, alg Algorithm) error {
+
+	var hash = HashFunc(alg)
 	var err error
 
 	pub, ok := publicKey.(*rsa.PublicKey)
 	if !ok {
-		return errors.New(ErrInvalidKey)
+		return ErrInvalidKey
 	}
 
-	/*
-		pub, err := x509.ParsePKCS1PublicKey(publicKey)
-		if err != nil {
-			return err
-		}
-	*/
-
 	if err = rsaCheckKeyLen(pub); err != nil {
 		return err
 	}
 
-	var hashed = doHash(message, hash)
+	switch alg {
+	case RS256, RS384, RS512:
+		err = rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+	case PS256, PS384, PS512:
+		err = rsa.VerifyPSS(pub, hash, digest, signature, pssOptions)
+	default:
+		return ErrInvalidAlgorithm
+	}
 
-	return rsa.VerifyPKCS1v15(pub, hash, hashed, signature)
+	if err != nil {
+		return ErrAlteredMessage
+	}
+	return nil
 }
 
-func translateAlgorithm(alg Algorithm) crypto.Hash {
-	switch alg {
-	case RS256, HS256:
-		return crypto.SHA256
-	case RS384, HS384:
-		return crypto.SHA384
-	case RS512, HS512:
-		return crypto.SHA512
-	default:
-		panic(ErrInvalidAlgorithm)
+//The salt of RSASSA-PSS has the size of the hash output: https://tools.ietf.org/html/rfc7518#section-3.5
+var pssOptions = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+
+//HashFunc returns the hash function used by alg, or zero if alg doesn't use one.
+func HashFunc(alg Algorithm) crypto.Hash {
+	impl, err := GetImplementation(alg)
+	if err != nil {
+		return 0
 	}
+	return impl.Hash
 }
 
 func doHash(message []byte, alg crypto.Hash) []byte {
@@ -102,7 +132,7 @@ func rsaCheckKeyLen(rsaKey interface{}) error {
 			return nil
 		}
 	default:
-		return errors.New(ErrInvalidKey)
+		return ErrInvalidKey
 	}
-	return errors.New(ErrInvalidKeyLength)
+	return ErrInvalidKeyLength
 }
@@ -3,6 +3,7 @@ package jwa
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"errors"
 	"testing"
 )
 
@@ -79,15 +80,34 @@ func Test_RSA_EnsureKeyLength(t *testing.T) {
 	signature, err := RSASign(testDefaultMessage, smallKey, RS256)
 	if err == nil {
 		t.Fatalf("Expected error due to key smaller than 2048 bits.")
-	} else if err.Error() != ErrInvalidKeyLength {
+	} else if !errors.Is(err, ErrInvalidKeyLength) {
 		t.Fatalf("Encountered error, but not the expected one: %v, found: %v", ErrInvalidKeyLength, err)
 	}
 
 	err = RSAVerify(testDefaultMessage, signature, &smallKey.PublicKey, RS256)
 	if err == nil {
 		t.Fatalf("Expected error due to key smaller than 2048 bits.")
-	} else if err.Error() != ErrInvalidKeyLength {
+	} else if !errors.Is(err, ErrInvalidKeyLength) {
 		t.Fatalf("Encountered error, but not the expected one: %v, found: %v", ErrInvalidKeyLength, err)
 	}
 
 }
+
+func Test_PSS_SignVerify(t *testing.T) {
+
+	for _, alg := range []Algorithm{PS256, PS384, PS512} {
+		sign, err := RSASign(testDefaultMessage, testRSAPrivateKey, alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = RSAVerify(testDefaultMessage, sign, testRSAPublicKey, alg); err != nil {
+			t.Fatal(err)
+		}
+
+		//A PSS signature is not a PKCS1 v1.5 one.
+		if err = RSAVerify(testDefaultMessage, sign, testRSAPublicKey, RS256+alg-PS256); err == nil {
+			t.Errorf("%s signature verified as PKCS1 v1.5", GetAlgorithmName(alg))
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package jwa
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+//secp256k1Curve is the Koblitz curve y² = x³ + 7 used by ES256K: https://tools.ietf.org/html/rfc8812#section-3.1
+//elliptic.CurveParams assumes a = -3, so the arithmetic is implemented here for a = 0.
+//The point at infinity is (0, 0), as in crypto/elliptic.
+type secp256k1Curve struct {
+	params *elliptic.CurveParams
+}
+
+var secp256k1 = newSecp256k1()
+
+func newSecp256k1() *secp256k1Curve {
+	var params = &elliptic.CurveParams{Name: ECSecp256k1Name, BitSize: 256}
+	params.P, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	params.N, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	params.B = big.NewInt(7)
+	params.Gx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	params.Gy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+	return &secp256k1Curve{params: params}
+}
+
+//Secp256k1 returns the secp256k1 curve used by ES256K, to be given to ecdsa.GenerateKey.
+//It's implemented with math/big, so it's not constant time: keys that sign where timing
+//can be observed should be kept behind a crypto.Signer, such as a HSM.
+func Secp256k1() elliptic.Curve {
+	return secp256k1
+}
+
+//Params implements elliptic.Curve.
+func (c *secp256k1Curve) Params() *elliptic.CurveParams {
+	return c.params
+}
+
+//IsOnCurve implements elliptic.Curve.
+func (c *secp256k1Curve) IsOnCurve(x, y *big.Int) bool {
+	var p = c.params.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+
+	var y2 = new(big.Int).Mul(y, y)
+	y2.Mod(y2, p)
+
+	var x3 = new(big.Int).Mul(x, x)
+	x3.Mul(x3, x)
+	x3.Add(x3, c.params.B)
+	x3.Mod(x3, p)
+
+	return y2.Cmp(x3) == 0
+}
+
+//Add implements elliptic.Curve.
+func (c *secp256k1Curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+
+	if isInfinity(x1, y1) {
+		return new(big.Int).Set(x2), new(big.Int).Set(y2)
+	}
+	if isInfinity(x2, y2) {
+		return new(big.Int).Set(x1), new(big.Int).Set(y1)
+	}
+
+	var p = c.params.P
+	if x1.Cmp(x2) == 0 {
+		if y1.Cmp(y2) == 0 {
+			return c.Double(x1, y1)
+		}
+		//P + (-P)
+		return new(big.Int), new(big.Int)
+	}
+
+	//λ = (y2 - y1) / (x2 - x1)
+	var lambda = new(big.Int).Sub(x2, x1)
+	lambda.Mod(lambda, p)
+	lambda.ModInverse(lambda, p)
+	lambda.Mul(lambda, new(big.Int).Sub(y2, y1))
+	lambda.Mod(lambda, p)
+
+	return c.lineResult(lambda, x1, y1, x2)
+}
+
+//Double implements elliptic.Curve.
+func (c *secp256k1Curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+
+	if isInfinity(x1, y1) || y1.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+
+	var p = c.params.P
+
+	//λ = 3x² / 2y, as a = 0
+	var lambda = new(big.Int).Lsh(y1, 1)
+	lambda.ModInverse(lambda, p)
+	var x2 = new(big.Int).Mul(x1, x1)
+	x2.Mul(x2, big.NewInt(3))
+	lambda.Mul(lambda, x2)
+	lambda.Mod(lambda, p)
+
+	return c.lineResult(lambda, x1, y1, x1)
+}
+
+//lineResult returns the third point of the line of slope lambda, reflected:
+//x3 = λ² - x1 - x2, y3 = λ(x1 - x3) - y1
+func (c *secp256k1Curve) lineResult(lambda, x1, y1, x2 *big.Int) (*big.Int, *big.Int) {
+	var p = c.params.P
+
+	var x3 = new(big.Int).Mul(lambda, lambda)
+	x3.Sub(x3, x1)
+	x3.Sub(x3, x2)
+	x3.Mod(x3, p)
+
+	var y3 = new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, lambda)
+	y3.Sub(y3, y1)
+	y3.Mod(y3, p)
+
+	return x3, y3
+}
+
+//ScalarMult implements elliptic.Curve.
+func (c *secp256k1Curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+
+	var x, y = new(big.Int), new(big.Int)
+	for _, b := range k {
+		for bit := 7; bit >= 0; bit-- {
+			x, y = c.Double(x, y)
+			if b>>uint(bit)&1 == 1 {
+				x, y = c.Add(x, y, x1, y1)
+			}
+		}
+	}
+	return x, y
+}
+
+//ScalarBaseMult implements elliptic.Curve.
+func (c *secp256k1Curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.params.Gx, c.params.Gy, k)
+}
+
+func isInfinity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
+}
@@ -0,0 +1,82 @@
+package jwa
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func hexInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 16)
+	return n
+}
+
+func Test_Secp256k1_Arithmetic(t *testing.T) {
+
+	var c = Secp256k1()
+	var params = c.Params()
+
+	if !c.IsOnCurve(params.Gx, params.Gy) {
+		t.Fatal("G is not on the curve")
+	}
+
+	//2G and 3G, as known points of the curve.
+	var x2, y2 = hexInt("C6047F9441ED7D6D3045406E95C07CD85C778E4B8CEF3CA7ABAC09B95C709EE5"),
+		hexInt("1AE168FEA63DC339A3C58419466CEAEEF7F632653266D0E1236431A950CFE52A")
+	var x3, y3 = hexInt("F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9"),
+		hexInt("388F7B0F632DE8140FE337E62A37F3566500A99934C2231B6CB9FD7584B8E672")
+
+	if x, y := c.Double(params.Gx, params.Gy); x.Cmp(x2) != 0 || y.Cmp(y2) != 0 {
+		t.Errorf("Unexpected 2G: %x, %x", x, y)
+	}
+
+	if x, y := c.Add(x2, y2, params.Gx, params.Gy); x.Cmp(x3) != 0 || y.Cmp(y3) != 0 {
+		t.Errorf("Unexpected 3G: %x, %x", x, y)
+	}
+
+	if x, y := c.ScalarBaseMult([]byte{3}); x.Cmp(x3) != 0 || y.Cmp(y3) != 0 {
+		t.Errorf("Unexpected 3G: %x, %x", x, y)
+	}
+
+	//The order of G is N.
+	if x, y := c.ScalarBaseMult(params.N.Bytes()); x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("NG is not the point at infinity: %x, %x", x, y)
+	}
+
+	if c.IsOnCurve(params.Gx, new(big.Int).Add(params.Gy, big.NewInt(1))) {
+		t.Error("Point wrongly on the curve")
+	}
+}
+
+func Test_ES256K_SignVerify(t *testing.T) {
+
+	priv, err := ecdsa.GenerateKey(Secp256k1(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, s, err := EllipticSign(testDefaultMessage, priv, ES256K)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = EllipticVerify(testDefaultMessage, &priv.PublicKey, r, s, ES256K); err != nil {
+		t.Error(err)
+	}
+
+	signature, err := EncodeEllipticSignature(r, s, ES256K)
+	if err != nil || len(signature) != ESP256Octets {
+		t.Errorf("Unexpected signature: %d bytes, %v", len(signature), err)
+	}
+
+	if err = EllipticVerify([]byte("altered"), &priv.PublicKey, r, s, ES256K); !errors.Is(err, ErrAlteredMessage) {
+		t.Errorf("Expected %s, found %v", ErrAlteredMessage, err)
+	}
+
+	//A P-256 key cannot be used with ES256K, nor a secp256k1 key with ES256.
+	if _, _, err = EllipticSign(testDefaultMessage, priv, ES256); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("Expected %s, found %v", ErrInvalidCurve, err)
+	}
+}
@@ -0,0 +1,82 @@
+package jwk
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/mldsa"
+	"crypto/rand"
+	"crypto/rsa"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+)
+
+//RSAGenerateBits is the size of the RSA keys created by Generate.
+var RSAGenerateBits = jwa.RSAMinBitLength
+
+//Generate creates a new key for alg with a random kid, and returns it both as a Go key,
+//ready for jws.Options.SetPrivateKey, and as a private JWK tagged with its "alg" and "use".
+//The key sizes are the ones required by JWA: https://tools.ietf.org/html/rfc7518#section-3
+//  HSXXX: a secret as long as the hash output, as a []byte.
+//  RSXXX, PSXXX: a RSA key of RSAGenerateBits.
+//  ESXXX: a key on the curve of the algorithm.
+//  EdDSA: an Ed25519 key.
+//  ML-DSA-XX: a key of the parameter set of the algorithm.
+func Generate(alg jwa.Algorithm) (crypto.PrivateKey, *Key, error) {
+
+	var key crypto.PrivateKey
+	var err error
+
+	switch alg {
+	case jwa.HS256, jwa.HS384, jwa.HS512:
+		var secret = make([]byte, jwa.HashFunc(alg).Size())
+		if _, err = rand.Read(secret); err != nil {
+			return nil, nil, err
+		}
+		key = secret
+	case jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512:
+		key, err = rsa.GenerateKey(rand.Reader, RSAGenerateBits)
+	case jwa.ES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwa.ES384:
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case jwa.ES512:
+		key, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case jwa.ES256K:
+		key, err = ecdsa.GenerateKey(jwa.Secp256k1(), rand.Reader)
+	case jwa.EdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case jwa.MLDSA44, jwa.MLDSA65, jwa.MLDSA87:
+		params, _ := jwa.MLDSAParameters(alg)
+		key, err = mldsa.GenerateKey(params)
+	default:
+		return nil, nil, jwa.ErrInvalidAlgorithm
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jwk, err := FromKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if jwk.KeyID, err = randomKeyID(); err != nil {
+		return nil, nil, err
+	}
+	jwk.Algorithm = jwa.GetAlgorithmName(alg)
+	jwk.Use = UseSignature
+
+	return key, jwk, nil
+}
+
+func randomKeyID() (string, error) {
+	var id = make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return b64.EncodeURL(id), nil
+}
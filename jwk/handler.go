@@ -0,0 +1,88 @@
+package jwk
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vegaj/JOSE/b64"
+)
+
+//ContentTypeSet is the media type of a JWK Set: https://tools.ietf.org/html/rfc7517#section-8.5.1
+const ContentTypeSet = `application/jwk-set+json`
+
+//Handler is an http.Handler that publishes a JWK Set, usually at /.well-known/jwks.json.
+//Only the public members of the keys are served, whatever Keys returns.
+type Handler struct {
+	//Keys returns the set to publish, such as the PublicSet method of a jws.KeyManager
+	//or the Public method of a Set.
+	Keys func() Set
+	//MaxAge is how long clients may cache the set. It must be shorter than the time
+	//a new key is published before it signs. Zero disables caching.
+	MaxAge time.Duration
+}
+
+//NewHandler returns a Handler that publishes the set returned by keys.
+func NewHandler(keys func() Set, maxAge time.Duration) *Handler {
+	return &Handler{Keys: keys, MaxAge: maxAge}
+}
+
+//ServeHTTP answers GET and HEAD requests with the JWK Set, its ETag and its Cache-Control.
+//Requests whose If-None-Match matches the ETag get a 304 Not Modified.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var set = Set{Keys: []Key{}}
+	if h.Keys != nil {
+		set = h.Keys().Public()
+	}
+
+	body, err := json.Marshal(set)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var sum = sha256.Sum256(body)
+	var etag = `"` + b64.EncodeURL(sum[:]) + `"`
+
+	var header = w.Header()
+	header.Set("ETag", etag)
+	if h.MaxAge > 0 {
+		header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(h.MaxAge/time.Second)))
+	} else {
+		header.Set("Cache-Control", "no-cache")
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Type", ContentTypeSet)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		w.Write(body)
+	}
+}
+
+//etagMatches reports whether the If-None-Match list has etag, compared weakly:
+//https://tools.ietf.org/html/rfc7232#section-3.2
+func etagMatches(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package jwk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+)
+
+func Test_Handler_Serve(t *testing.T) {
+
+	var set Set
+	for _, alg := range []jwa.Algorithm{jwa.ES256, jwa.EdDSA, jwa.HS256} {
+		_, k, err := Generate(alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set.Keys = append(set.Keys, *k)
+	}
+
+	//The private set is given on purpose: the handler must strip it.
+	h := NewHandler(func() Set { return set }, 10*time.Minute)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected %d, found %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != ContentTypeSet {
+		t.Errorf("Unexpected Content-Type: %s", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=600" {
+		t.Errorf("Unexpected Cache-Control: %s", cc)
+	}
+
+	var raw struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Keys) != 2 {
+		t.Errorf("Expected 2 keys, found %d", len(raw.Keys))
+	}
+	for _, k := range raw.Keys {
+		for _, member := range []string{"d", "p", "q", "dp", "dq", "qi", "k", "priv"} {
+			if _, ok := k[member]; ok {
+				t.Errorf("The private member %s was published: %v", member, k)
+			}
+		}
+	}
+
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Missing ETag")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("Expected %d, found %d", http.StatusNotModified, rec.Code)
+	}
+
+	//A new key changes the ETag.
+	_, k, _ := Generate(jwa.ES384)
+	set.Keys = append(set.Keys, *k)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("Expected a new set, found %d %s", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func Test_Handler_Methods(t *testing.T) {
+
+	h := NewHandler(nil, 0)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/", nil))
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 || rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("Unexpected HEAD response: %d %v", rec.Code, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("Unexpected POST response: %d %v", rec.Code, rec.Header())
+	}
+}
@@ -0,0 +1,470 @@
+//Package jwk implements JSON Web Keys as described in https://tools.ietf.org/html/rfc7517
+package jwk
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/mldsa"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+)
+
+const (
+	//KeyTypeEC identifies Elliptic Curve keys: https://tools.ietf.org/html/rfc7518#section-6.2
+	KeyTypeEC = `EC`
+	//KeyTypeRSA identifies RSA keys: https://tools.ietf.org/html/rfc7518#section-6.3
+	KeyTypeRSA = `RSA`
+	//KeyTypeOct identifies symmetric keys: https://tools.ietf.org/html/rfc7518#section-6.4
+	KeyTypeOct = `oct`
+	//KeyTypeOKP identifies Octet Key Pairs, such as Ed25519: https://tools.ietf.org/html/rfc8037#section-2
+	KeyTypeOKP = `OKP`
+	//KeyTypeAKP identifies Algorithm Key Pairs, such as ML-DSA: https://datatracker.ietf.org/doc/draft-ietf-cose-dilithium/
+	KeyTypeAKP = `AKP`
+
+	//UseSignature is the "use" of the keys that sign or verify.
+	UseSignature = `sig`
+)
+
+var (
+	//ErrUnsupportedKey means that the key type or curve cannot be represented or used.
+	ErrUnsupportedKey = errors.New("unsupported key")
+	//ErrMissingMember means that a member required by the key type is not present or malformed.
+	ErrMissingMember = errors.New("missing or malformed key member")
+	//ErrKeyNotFound means that a JWK Set has no key with the requested kid.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+//Key is a JSON Web Key. The binary members are kept base64url encoded, as in their JSON form.
+type Key struct {
+	KeyType   string   `json:"kty"`
+	Use       string   `json:"use,omitempty"`
+	KeyOps    []string `json:"key_ops,omitempty"`
+	Algorithm string   `json:"alg,omitempty"`
+	KeyID     string   `json:"kid,omitempty"`
+
+	//EC and OKP members
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+
+	//RSA public members
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	//Private member of EC, OKP and RSA keys.
+	D string `json:"d,omitempty"`
+
+	//RSA private members
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	DP string `json:"dp,omitempty"`
+	DQ string `json:"dq,omitempty"`
+	QI string `json:"qi,omitempty"`
+
+	//Symmetric key value
+	K string `json:"k,omitempty"`
+
+	//AKP members. The key is bound to the algorithm given in "alg".
+	Pub  string `json:"pub,omitempty"`
+	Priv string `json:"priv,omitempty"`
+}
+
+//Set is a JWK Set: https://tools.ietf.org/html/rfc7517#section-5
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+//FromKey returns the JWK representation of a Go key. It takes RSA, ECDSA, Ed25519 and ML-DSA
+//private or public keys, and []byte symmetric keys.
+func FromKey(key interface{}) (*Key, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var jwk = fromRSAPublic(&k.PublicKey)
+		if len(k.Primes) != 2 {
+			return nil, ErrUnsupportedKey
+		}
+		var crt = k.Precomputed
+		if crt.Dp == nil || crt.Dq == nil || crt.Qinv == nil {
+			//The key of the caller may be in use elsewhere, so it's computed on a copy.
+			var c = rsa.PrivateKey{PublicKey: k.PublicKey, D: k.D, Primes: k.Primes}
+			c.Precompute()
+			crt = c.Precomputed
+		}
+		jwk.D = b64.EncodeURL(k.D.Bytes())
+		jwk.P = b64.EncodeURL(k.Primes[0].Bytes())
+		jwk.Q = b64.EncodeURL(k.Primes[1].Bytes())
+		jwk.DP = b64.EncodeURL(crt.Dp.Bytes())
+		jwk.DQ = b64.EncodeURL(crt.Dq.Bytes())
+		jwk.QI = b64.EncodeURL(crt.Qinv.Bytes())
+		return jwk, nil
+	case *rsa.PublicKey:
+		return fromRSAPublic(k), nil
+	case *ecdsa.PrivateKey:
+		jwk, err := fromECPublic(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		jwk.D = b64.EncodeURL(k.D.FillBytes(make([]byte, coordinateSize(k.Curve))))
+		return jwk, nil
+	case *ecdsa.PublicKey:
+		return fromECPublic(k)
+	case ed25519.PrivateKey:
+		if len(k) != ed25519.PrivateKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return &Key{KeyType: KeyTypeOKP, Curve: jwa.Ed25519Name, X: b64.EncodeURL(k[32:]), D: b64.EncodeURL(k.Seed())}, nil
+	case ed25519.PublicKey:
+		if len(k) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return &Key{KeyType: KeyTypeOKP, Curve: jwa.Ed25519Name, X: b64.EncodeURL(k)}, nil
+	case *mldsa.PrivateKey:
+		jwk, err := fromAKPPublic(k.PublicKey())
+		if err != nil {
+			return nil, err
+		}
+		jwk.Priv = b64.EncodeURL(k.Bytes())
+		return jwk, nil
+	case *mldsa.PublicKey:
+		return fromAKPPublic(k)
+	case []byte:
+		if len(k) == 0 {
+			return nil, ErrUnsupportedKey
+		}
+		return &Key{KeyType: KeyTypeOct, K: b64.EncodeURL(k)}, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+func fromRSAPublic(k *rsa.PublicKey) *Key {
+	return &Key{KeyType: KeyTypeRSA, N: b64.EncodeURL(k.N.Bytes()), E: b64.EncodeURL(big.NewInt(int64(k.E)).Bytes())}
+}
+
+func fromECPublic(k *ecdsa.PublicKey) (*Key, error) {
+	var crv = curveName(k.Curve)
+	if crv == "" {
+		return nil, ErrUnsupportedKey
+	}
+
+	//The coordinates have the full size of the curve: https://tools.ietf.org/html/rfc7518#section-6.2.1.2
+	var size = coordinateSize(k.Curve)
+	return &Key{
+		KeyType: KeyTypeEC,
+		Curve:   crv,
+		X:       b64.EncodeURL(k.X.FillBytes(make([]byte, size))),
+		Y:       b64.EncodeURL(k.Y.FillBytes(make([]byte, size))),
+	}, nil
+}
+
+//fromAKPPublic sets "alg" too, as it's required by the AKP keys.
+func fromAKPPublic(k *mldsa.PublicKey) (*Key, error) {
+	var alg = jwa.AlgorithmFromName(k.Parameters().String())
+	if _, err := jwa.MLDSAParameters(alg); err != nil {
+		return nil, ErrUnsupportedKey
+	}
+	return &Key{KeyType: KeyTypeAKP, Algorithm: jwa.GetAlgorithmName(alg), Pub: b64.EncodeURL(k.Bytes())}, nil
+}
+
+//IsPrivate reports whether the key holds private or symmetric material.
+func (k Key) IsPrivate() bool {
+	return k.D != "" || k.K != "" || k.Priv != ""
+}
+
+//Public returns a copy of the key without its private members.
+//A symmetric key has no public part, so its copy has no key value at all.
+func (k Key) Public() Key {
+	k.D, k.P, k.Q, k.DP, k.DQ, k.QI, k.K, k.Priv = "", "", "", "", "", "", "", ""
+	if k.KeyOps != nil {
+		k.KeyOps = append([]string(nil), k.KeyOps...)
+	}
+	return k
+}
+
+//PublicKey returns the Go public key of k.
+func (k Key) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case KeyTypeRSA:
+		return k.rsaPublicKey()
+	case KeyTypeEC:
+		return k.ecPublicKey()
+	case KeyTypeOKP:
+		if k.Curve != jwa.Ed25519Name {
+			return nil, ErrUnsupportedKey
+		}
+		x, err := decodeMember(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, ErrMissingMember
+		}
+		return ed25519.PublicKey(x), nil
+	case KeyTypeAKP:
+		return k.akpPublicKey()
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+//PrivateKey returns the Go private key of k, or the []byte value of a symmetric key.
+func (k Key) PrivateKey() (crypto.PrivateKey, error) {
+
+	if k.KeyType == KeyTypeOct {
+		key, err := decodeMember(k.K)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+
+	if k.KeyType == KeyTypeAKP {
+		return k.akpPrivateKey()
+	}
+
+	if k.D == "" {
+		return nil, ErrMissingMember
+	}
+
+	d, err := decodeMember(k.D)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k.KeyType {
+	case KeyTypeRSA:
+		pub, err := k.rsaPublicKey()
+		if err != nil {
+			return nil, err
+		}
+		p, err := decodeMember(k.P)
+		if err != nil {
+			return nil, err
+		}
+		q, err := decodeMember(k.Q)
+		if err != nil {
+			return nil, err
+		}
+		priv := &rsa.PrivateKey{
+			PublicKey: *pub,
+			D:         new(big.Int).SetBytes(d),
+			Primes:    []*big.Int{new(big.Int).SetBytes(p), new(big.Int).SetBytes(q)},
+		}
+		if err = priv.Validate(); err != nil {
+			return nil, ErrMissingMember
+		}
+		priv.Precompute()
+		return priv, nil
+	case KeyTypeEC:
+		pub, err := k.ecPublicKey()
+		if err != nil {
+			return nil, err
+		}
+		priv, err := ecPrivateKey(pub.Curve, d)
+		if err != nil || !priv.PublicKey.Equal(pub) {
+			return nil, ErrMissingMember
+		}
+		return priv, nil
+	case KeyTypeOKP:
+		pub, err := k.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		if len(d) != ed25519.SeedSize {
+			return nil, ErrMissingMember
+		}
+		priv := ed25519.NewKeyFromSeed(d)
+		if !priv.Public().(ed25519.PublicKey).Equal(pub) {
+			return nil, ErrMissingMember
+		}
+		return priv, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+func (k Key) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := decodeMember(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeMember(k.E)
+	if err != nil || len(e) > 4 {
+		return nil, ErrMissingMember
+	}
+
+	var exponent = new(big.Int).SetBytes(e)
+	if exponent.Sign() <= 0 || exponent.Int64() > 1<<31-1 {
+		return nil, ErrMissingMember
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func (k Key) ecPublicKey() (*ecdsa.PublicKey, error) {
+	var curve = curveByName(k.Curve)
+	if curve == nil {
+		return nil, ErrUnsupportedKey
+	}
+
+	var size = coordinateSize(curve)
+	x, err := decodeMember(k.X)
+	if err != nil || len(x) != size {
+		return nil, ErrMissingMember
+	}
+	y, err := decodeMember(k.Y)
+	if err != nil || len(y) != size {
+		return nil, ErrMissingMember
+	}
+
+	//crypto/ecdsa only parses the NIST curves.
+	if curve == jwa.Secp256k1() {
+		var pub = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, ErrMissingMember
+		}
+		return pub, nil
+	}
+
+	//ParseUncompressedPublicKey also ensures that the point is on the curve.
+	var point = append(append([]byte{4}, x...), y...)
+	pub, err := ecdsa.ParseUncompressedPublicKey(curve, point)
+	if err != nil {
+		return nil, ErrMissingMember
+	}
+	return pub, nil
+}
+
+func (k Key) akpParameters() (mldsa.Parameters, error) {
+	params, err := jwa.MLDSAParameters(jwa.AlgorithmFromName(k.Algorithm))
+	if err != nil {
+		return mldsa.Parameters{}, ErrUnsupportedKey
+	}
+	return params, nil
+}
+
+func (k Key) akpPublicKey() (*mldsa.PublicKey, error) {
+	params, err := k.akpParameters()
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := decodeMember(k.Pub)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := mldsa.NewPublicKey(params, pub)
+	if err != nil {
+		return nil, ErrMissingMember
+	}
+	return key, nil
+}
+
+//akpPrivateKey ensures that "pub" is the public key of the seed in "priv".
+func (k Key) akpPrivateKey() (*mldsa.PrivateKey, error) {
+	pub, err := k.akpPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := decodeMember(k.Priv)
+	if err != nil {
+		return nil, err
+	}
+
+	params, _ := k.akpParameters()
+	priv, err := mldsa.NewPrivateKey(params, seed)
+	if err != nil || !priv.PublicKey().Equal(pub) {
+		return nil, ErrMissingMember
+	}
+	return priv, nil
+}
+
+func ecPrivateKey(curve elliptic.Curve, d []byte) (*ecdsa.PrivateKey, error) {
+
+	if curve != jwa.Secp256k1() {
+		return ecdsa.ParseRawPrivateKey(curve, d)
+	}
+
+	//crypto/ecdsa only parses the NIST curves.
+	var scalar = new(big.Int).SetBytes(d)
+	if len(d) != coordinateSize(curve) || scalar.Sign() == 0 || scalar.Cmp(curve.Params().N) >= 0 {
+		return nil, ErrMissingMember
+	}
+
+	var priv = &ecdsa.PrivateKey{D: scalar}
+	priv.PublicKey.Curve = curve
+	priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(d)
+	return priv, nil
+}
+
+//Lookup returns the key of the set identified by kid.
+func (s Set) Lookup(kid string) (*Key, error) {
+	for i := range s.Keys {
+		if s.Keys[i].KeyID == kid {
+			return &s.Keys[i], nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+//Public returns a copy of the set where every key has lost its private members.
+//Symmetric keys are left out, as they have no public part.
+func (s Set) Public() Set {
+	var public = Set{Keys: make([]Key, 0, len(s.Keys))}
+	for _, k := range s.Keys {
+		if k.KeyType == KeyTypeOct {
+			continue
+		}
+		public.Keys = append(public.Keys, k.Public())
+	}
+	return public
+}
+
+func decodeMember(value string) ([]byte, error) {
+	if value == "" {
+		return nil, ErrMissingMember
+	}
+	data, err := b64.DecodeURLStrict(value)
+	if err != nil {
+		return nil, ErrMissingMember
+	}
+	return data, nil
+}
+
+func curveName(c elliptic.Curve) string {
+	switch c {
+	case elliptic.P256():
+		return jwa.ECP256Name
+	case elliptic.P384():
+		return jwa.ECP384Name
+	case elliptic.P521():
+		return jwa.ECP521Name
+	case jwa.Secp256k1():
+		return jwa.ECSecp256k1Name
+	default:
+		return ""
+	}
+}
+
+func curveByName(name string) elliptic.Curve {
+	switch name {
+	case jwa.ECP256Name:
+		return elliptic.P256()
+	case jwa.ECP384Name:
+		return elliptic.P384()
+	case jwa.ECP521Name:
+		return elliptic.P521()
+	case jwa.ECSecp256k1Name:
+		return jwa.Secp256k1()
+	default:
+		return nil
+	}
+}
+
+func coordinateSize(c elliptic.Curve) int {
+	return (c.Params().BitSize + 7) / 8
+}
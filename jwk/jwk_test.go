@@ -0,0 +1,160 @@
+package jwk
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+)
+
+var testAlgorithms = []jwa.Algorithm{
+	jwa.HS256, jwa.HS384, jwa.HS512,
+	jwa.RS256, jwa.PS512,
+	jwa.ES256, jwa.ES384, jwa.ES512, jwa.ES256K,
+	jwa.EdDSA,
+	jwa.MLDSA44, jwa.MLDSA65, jwa.MLDSA87,
+}
+
+func Test_JWK_Generate(t *testing.T) {
+
+	var kids = map[string]bool{}
+	for _, alg := range testAlgorithms {
+		key, jwk, err := Generate(alg)
+		if err != nil {
+			t.Fatalf("%s: %v", jwa.GetAlgorithmName(alg), err)
+		}
+
+		if jwk.Algorithm != jwa.GetAlgorithmName(alg) || jwk.Use != UseSignature {
+			t.Errorf("%s: unexpected alg or use: %+v", jwa.GetAlgorithmName(alg), jwk)
+		}
+
+		if jwk.KeyID == "" || kids[jwk.KeyID] {
+			t.Errorf("%s: the kid is not fresh: %s", jwa.GetAlgorithmName(alg), jwk.KeyID)
+		}
+		kids[jwk.KeyID] = true
+
+		if !jwk.IsPrivate() {
+			t.Errorf("%s: the JWK must hold the private key", jwa.GetAlgorithmName(alg))
+		}
+
+		//The JWK must describe the very same key.
+		priv, err := jwk.PrivateKey()
+		if err != nil {
+			t.Fatalf("%s: %v", jwa.GetAlgorithmName(alg), err)
+		}
+
+		if !samePrivateKey(key, priv) {
+			t.Errorf("%s: different keys", jwa.GetAlgorithmName(alg))
+		}
+	}
+}
+
+func samePrivateKey(a, b crypto.PrivateKey) bool {
+	if k, ok := a.([]byte); ok {
+		other, ok := b.([]byte)
+		return ok && string(k) == string(other)
+	}
+	return a.(interface{ Equal(crypto.PrivateKey) bool }).Equal(b)
+}
+
+func Test_JWK_JSONRoundTrip(t *testing.T) {
+
+	for _, alg := range []jwa.Algorithm{jwa.RS256, jwa.ES512, jwa.EdDSA, jwa.MLDSA65, jwa.ES256K} {
+		key, jwk, err := Generate(alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := json.Marshal(jwk.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var parsed Key
+		if err = json.Unmarshal(data, &parsed); err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed.IsPrivate() {
+			t.Errorf("%s: private members leaked: %s", jwa.GetAlgorithmName(alg), data)
+		}
+
+		pub, err := parsed.PublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(key.(crypto.Signer).Public()) {
+			t.Errorf("%s: different public keys", jwa.GetAlgorithmName(alg))
+		}
+	}
+}
+
+//RFC 7517 Appendix A.1, first key.
+func Test_JWK_FromKey_RSA(t *testing.T) {
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var precomputed = priv.Precomputed
+
+	//The key of the caller is left untouched, even without its CRT values.
+	priv.Precomputed = rsa.PrecomputedValues{}
+	k, err := FromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.Precomputed.Dp != nil {
+		t.Error("The key of the caller was modified")
+	}
+
+	if k.DP != b64.EncodeURL(precomputed.Dp.Bytes()) || k.DQ != b64.EncodeURL(precomputed.Dq.Bytes()) || k.QI != b64.EncodeURL(precomputed.Qinv.Bytes()) {
+		t.Error("Unexpected CRT values")
+	}
+}
+
+func Test_JWK_RFC7517_EC(t *testing.T) {
+	var data = `{"kty":"EC","crv":"P-256","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+		"y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM","use":"enc","kid":"1"}`
+
+	var key Key
+	if err := json.Unmarshal([]byte(data), &key); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := key.PublicKey(); err != nil {
+		t.Error(err)
+	}
+
+	//Not on the curve
+	key.Y = "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyQ"
+	if _, err := key.PublicKey(); !errors.Is(err, ErrMissingMember) {
+		t.Errorf("Expected %s, found %v", ErrMissingMember, err)
+	}
+}
+
+func Test_JWK_SetLookup(t *testing.T) {
+	_, ec, _ := Generate(jwa.ES256)
+	_, hs, _ := Generate(jwa.HS256)
+
+	var set = Set{Keys: []Key{*ec, *hs}}
+
+	if k, err := set.Lookup(ec.KeyID); err != nil || k.KeyID != ec.KeyID {
+		t.Errorf("Key not found: %v", err)
+	}
+
+	if _, err := set.Lookup("unknown"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected %s, found %v", ErrKeyNotFound, err)
+	}
+
+	public := set.Public()
+	if len(public.Keys) != 1 || public.Keys[0].IsPrivate() {
+		t.Errorf("Unexpected public set: %+v", public)
+	}
+}
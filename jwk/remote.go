@@ -0,0 +1,265 @@
+package jwk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//ErrFetchSet means that a remote JWK Set could not be fetched or parsed.
+var ErrFetchSet = errors.New("cannot fetch the JWK Set")
+
+const (
+	//DefaultSetMaxAge is how long a RemoteSet keeps a set whose response has no Cache-Control max-age.
+	DefaultSetMaxAge = time.Hour
+	//DefaultSetMinRefresh is the shortest time between two fetches of a RemoteSet, so unknown
+	//"kid" values cannot make it hammer the server.
+	DefaultSetMinRefresh = time.Minute
+	//DefaultSetTimeout bounds each fetch of a RemoteSet with no Timeout.
+	DefaultSetTimeout = 10 * time.Second
+	//maxSetSize bounds the responses read by a RemoteSet.
+	maxSetSize = 1 << 20
+)
+
+//RemoteSet is a JWK Set published at a URL, such as the jwks_uri of an issuer. It's kept
+//for the max-age of its response, and revalidated with its ETag once it's stale. When
+//a refresh fails, the last set is kept. It's safe for concurrent use: a single fetch
+//runs at a time, and it doesn't block the readers of a set that was already fetched.
+type RemoteSet struct {
+	//URL is the address of the set.
+	URL string
+	//Client fetches the set. http.DefaultClient is used if nil.
+	Client *http.Client
+	//MaxAge is how long the set is kept when the response has no max-age. DefaultSetMaxAge is used if zero.
+	MaxAge time.Duration
+	//MinRefresh is the shortest time between two fetches. DefaultSetMinRefresh is used if zero.
+	MinRefresh time.Duration
+	//Timeout bounds each fetch, whatever the Client. DefaultSetTimeout is used if zero.
+	Timeout time.Duration
+	//OnError is called with the error of every failed fetch, after which the last set is kept.
+	//The errors are logged if nil.
+	OnError func(err error)
+	//Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	set     Set
+	etag    string
+	fetched time.Time
+	expires time.Time
+	loaded  bool
+	err     error
+	//inflight is closed when the running fetch is over.
+	inflight chan struct{}
+}
+
+//NewRemoteSet returns a RemoteSet for url, fetched with client.
+func NewRemoteSet(url string, client *http.Client) *RemoteSet {
+	return &RemoteSet{URL: url, Client: client}
+}
+
+func (r *RemoteSet) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+//Keys returns the public keys of the set, refreshing them if they are stale. Only the first
+//call waits for the fetch; the next ones get the last set while it's refreshed.
+//It has the signature that jws.KeySet expects.
+func (r *RemoteSet) Keys() Set {
+
+	r.mu.Lock()
+	var stale, loaded = r.now().After(r.expires), r.loaded
+	r.mu.Unlock()
+
+	if stale {
+		r.refresh(context.Background(), !loaded)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.set.Public()
+}
+
+//Lookup returns the public key identified by kid. An unknown kid refreshes the set,
+//as the issuer may have rotated its keys, unless it was fetched within MinRefresh.
+//If that refresh fails, its error is returned along with ErrKeyNotFound.
+func (r *RemoteSet) Lookup(kid string) (*Key, error) {
+
+	var set = r.Keys()
+	if k, err := set.Lookup(kid); err == nil {
+		return k, nil
+	}
+
+	if err := r.refresh(context.Background(), true); err != nil {
+		return nil, errors.Join(ErrKeyNotFound, err)
+	}
+
+	r.mu.Lock()
+	set = r.set.Public()
+	r.mu.Unlock()
+	return set.Lookup(kid)
+}
+
+//Refresh fetches the set now, unless a fetch was attempted within MinRefresh, in which
+//case the error of that attempt is returned. If a fetch is already running, it waits for it instead.
+func (r *RemoteSet) Refresh(ctx context.Context) error {
+	return r.refresh(ctx, true)
+}
+
+//refresh starts a fetch, or joins the running one. It waits for the fetch only if wait is set.
+func (r *RemoteSet) refresh(ctx context.Context, wait bool) error {
+
+	var minRefresh = r.MinRefresh
+	if minRefresh <= 0 {
+		minRefresh = DefaultSetMinRefresh
+	}
+
+	r.mu.Lock()
+	var now = r.now()
+	var done = r.inflight
+	if done == nil {
+		//Failed fetches are throttled too, so a server that is down isn't flooded.
+		if !r.fetched.IsZero() && now.Sub(r.fetched) < minRefresh {
+			var err = r.err
+			r.mu.Unlock()
+			return err
+		}
+
+		done = make(chan struct{})
+		r.inflight = done
+		r.fetched = now
+		var etag = r.etag
+		if !r.loaded {
+			etag = ""
+		}
+
+		//The fetch is not tied to ctx, as other callers may wait for it.
+		go r.fetch(now, etag, done)
+	}
+	r.mu.Unlock()
+
+	if !wait {
+		return nil
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+//fetch gets the set and stores it, then closes done.
+func (r *RemoteSet) fetch(now time.Time, etag string, done chan struct{}) {
+
+	var timeout = r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSetTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	set, etag, maxAge, notModified, err := r.get(ctx, etag)
+	if err != nil {
+		r.reportError(err)
+	}
+
+	r.mu.Lock()
+	r.err = err
+	if err == nil {
+		if !notModified {
+			r.set, r.etag, r.loaded = set, etag, true
+		}
+		r.expires = now.Add(maxAge)
+	}
+	r.inflight = nil
+	r.mu.Unlock()
+
+	close(done)
+}
+
+//get requests the set, conditionally if etag is not empty.
+func (r *RemoteSet) get(ctx context.Context, etag string) (Set, string, time.Duration, bool, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return Set{}, "", 0, false, err
+	}
+	req.Header.Set("Accept", ContentTypeSet+", application/json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	var client = r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return Set{}, "", 0, false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusNotModified:
+		if etag == "" {
+			return Set{}, "", 0, false, ErrFetchSet
+		}
+		return Set{}, etag, r.maxAge(res), true, nil
+	case http.StatusOK:
+	default:
+		return Set{}, "", 0, false, ErrFetchSet
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxSetSize))
+	if err != nil {
+		return Set{}, "", 0, false, err
+	}
+
+	var set Set
+	if err = json.Unmarshal(body, &set); err != nil || set.Keys == nil {
+		return Set{}, "", 0, false, ErrFetchSet
+	}
+	return set, res.Header.Get("ETag"), r.maxAge(res), false, nil
+}
+
+func (r *RemoteSet) reportError(err error) {
+	if r.OnError != nil {
+		r.OnError(err)
+		return
+	}
+	log.Println("Refreshing the JWK Set", r.URL, "failed:", err)
+}
+
+//maxAge returns the max-age of the Cache-Control header of res, or MaxAge.
+func (r *RemoteSet) maxAge(res *http.Response) time.Duration {
+
+	for _, directive := range strings.Split(res.Header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if strings.EqualFold(name, "max-age") {
+			if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	if r.MaxAge > 0 {
+		return r.MaxAge
+	}
+	return DefaultSetMaxAge
+}
@@ -0,0 +1,199 @@
+package jwk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+)
+
+func Test_RemoteSet_Cache(t *testing.T) {
+
+	var set Set
+	_, k, _ := Generate(jwa.ES256)
+	set.Keys = append(set.Keys, *k)
+
+	var requests, modified int32
+	handler := NewHandler(func() Set { return set }, time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if rec.Code == http.StatusOK {
+			atomic.AddInt32(&modified, 1)
+		}
+		for name, values := range rec.Header() {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	}))
+	defer server.Close()
+
+	var now = time.Now()
+	remote := NewRemoteSet(server.URL, server.Client())
+	remote.MinRefresh = 10 * time.Second
+	remote.Now = func() time.Time { return now }
+
+	if keys := remote.Keys(); len(keys.Keys) != 1 || keys.Keys[0].IsPrivate() {
+		t.Fatalf("Unexpected set: %+v", keys)
+	}
+
+	//The set is kept for the max-age of the response.
+	now = now.Add(30 * time.Second)
+	remote.Keys()
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected 1 request, found %d", requests)
+	}
+
+	//Then it's revalidated with its ETag, while the last set is served.
+	now = now.Add(time.Minute)
+	if keys := remote.Keys(); len(keys.Keys) != 1 {
+		t.Errorf("Unexpected set: %+v", keys)
+	}
+	remote.Refresh(t.Context())
+	if atomic.LoadInt32(&requests) != 2 || atomic.LoadInt32(&modified) != 1 {
+		t.Errorf("Expected a revalidation, found %d requests and %d sets", requests, modified)
+	}
+
+	//An unknown kid refreshes the set, once per MinRefresh.
+	_, rotated, _ := Generate(jwa.ES256)
+	set.Keys = append(set.Keys, *rotated)
+	now = now.Add(15 * time.Second)
+	if _, err := remote.Lookup(rotated.KeyID); err != nil {
+		t.Error(err)
+	}
+	if _, err := remote.Lookup("unknown"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected %s, found %v", ErrKeyNotFound, err)
+	}
+	if atomic.LoadInt32(&requests) != 3 {
+		t.Errorf("Expected 3 requests, found %d", requests)
+	}
+}
+
+func Test_RemoteSet_Failure(t *testing.T) {
+
+	var fail bool
+	_, k, _ := Generate(jwa.EdDSA)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		NewHandler(func() Set { return Set{Keys: []Key{*k}} }, 0).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	var now = time.Now()
+	remote := NewRemoteSet(server.URL, server.Client())
+	remote.Now = func() time.Time { return now }
+
+	if err := remote.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	fail = true
+	now = now.Add(2 * time.Hour)
+	if err := remote.Refresh(t.Context()); !errors.Is(err, ErrFetchSet) {
+		t.Errorf("Expected %s, found %v", ErrFetchSet, err)
+	}
+	if _, err := remote.Lookup(k.KeyID); err != nil {
+		t.Errorf("The last set was not kept: %v", err)
+	}
+}
+
+func Test_RemoteSet_Down(t *testing.T) {
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var now = time.Now()
+	remote := NewRemoteSet(server.URL, server.Client())
+	remote.Now = func() time.Time { return now }
+	remote.OnError = func(error) {}
+
+	//Unknown kids cannot make it flood a server that never answered.
+	for range 20 {
+		if _, err := remote.Lookup("unknown"); !errors.Is(err, ErrKeyNotFound) || !errors.Is(err, ErrFetchSet) {
+			t.Fatalf("Expected %s and %s, found %v", ErrKeyNotFound, ErrFetchSet, err)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected 1 request, found %d", n)
+	}
+
+	now = now.Add(DefaultSetMinRefresh)
+	remote.Lookup("unknown")
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected 2 requests, found %d", n)
+	}
+}
+
+func Test_RemoteSet_Hung(t *testing.T) {
+
+	_, k, _ := Generate(jwa.ES256)
+	var hang atomic.Bool
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if hang.Load() {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		NewHandler(func() Set { return Set{Keys: []Key{*k}} }, 0).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	var failures int32
+	remote := NewRemoteSet(server.URL, server.Client())
+	remote.Timeout = 100 * time.Millisecond
+	remote.MinRefresh = time.Nanosecond
+	remote.OnError = func(error) { atomic.AddInt32(&failures, 1) }
+
+	if _, err := remote.Lookup(k.KeyID); err != nil {
+		t.Fatal(err)
+	}
+
+	//While the server hangs, the known keys are still served and one fetch at a time is made.
+	hang.Store(true)
+	var start = time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := remote.Lookup(k.KeyID); err != nil {
+				t.Error(err)
+			}
+			if _, err := remote.Lookup("rotated"); !errors.Is(err, ErrKeyNotFound) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected %s and %s, found %v", ErrKeyNotFound, context.DeadlineExceeded, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("The lookups took %s", elapsed)
+	}
+	if n := atomic.LoadInt32(&requests); n > 1+8 {
+		t.Errorf("Expected shared fetches, found %d requests", n)
+	}
+	if atomic.LoadInt32(&failures) == 0 {
+		t.Error("The failed fetches were not reported")
+	}
+}
@@ -0,0 +1,93 @@
+package jwk
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+
+	"github.com/vegaj/JOSE/b64"
+)
+
+//ThumbprintURIPrefix starts the JWK Thumbprint URIs: https://tools.ietf.org/html/rfc9278#section-3
+const ThumbprintURIPrefix = `urn:ietf:params:oauth:jwk-thumbprint:`
+
+//thumbprintMembers returns the required members of the key, the only ones that are hashed.
+//See https://tools.ietf.org/html/rfc7638#section-3.2
+func (k Key) thumbprintMembers() (map[string]string, error) {
+
+	var members map[string]string
+	switch k.KeyType {
+	case KeyTypeEC:
+		members = map[string]string{"crv": k.Curve, "x": k.X, "y": k.Y}
+	case KeyTypeRSA:
+		members = map[string]string{"e": k.E, "n": k.N}
+	case KeyTypeOct:
+		members = map[string]string{"k": k.K}
+	case KeyTypeOKP:
+		//https://tools.ietf.org/html/rfc8037#section-2
+		members = map[string]string{"crv": k.Curve, "x": k.X}
+	case KeyTypeAKP:
+		members = map[string]string{"alg": k.Algorithm, "pub": k.Pub}
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	for _, v := range members {
+		if v == "" {
+			return nil, ErrMissingMember
+		}
+	}
+
+	members["kty"] = k.KeyType
+	return members, nil
+}
+
+//Thumbprint returns the JWK Thumbprint of the key computed with h: the hash of the
+//required members of the key, written in lexicographic order and without whitespace.
+//See https://tools.ietf.org/html/rfc7638
+func (k Key) Thumbprint(h crypto.Hash) ([]byte, error) {
+
+	if !h.Available() {
+		return nil, ErrUnsupportedKey
+	}
+
+	members, err := k.thumbprintMembers()
+	if err != nil {
+		return nil, err
+	}
+
+	//json.Marshal sorts the keys of a map, but it would escape some characters.
+	var buf bytes.Buffer
+	var enc = json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err = enc.Encode(members); err != nil {
+		return nil, err
+	}
+
+	var hash = h.New()
+	hash.Write(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+	return hash.Sum(nil), nil
+}
+
+//ThumbprintURI returns the JWK Thumbprint URI of the key computed with h, which must be
+//SHA-256, SHA-384 or SHA-512: https://tools.ietf.org/html/rfc9278
+func (k Key) ThumbprintURI(h crypto.Hash) (string, error) {
+
+	var name string
+	switch h {
+	case crypto.SHA256:
+		name = "sha-256"
+	case crypto.SHA384:
+		name = "sha-384"
+	case crypto.SHA512:
+		name = "sha-512"
+	default:
+		return "", ErrUnsupportedKey
+	}
+
+	thumbprint, err := k.Thumbprint(h)
+	if err != nil {
+		return "", err
+	}
+	return ThumbprintURIPrefix + name + ":" + b64.EncodeURL(thumbprint), nil
+}
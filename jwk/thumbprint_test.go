@@ -0,0 +1,80 @@
+package jwk
+
+import (
+	"crypto"
+	"errors"
+	"testing"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+)
+
+//RFC 7638 Section 3.1 and RFC 9278 Section 3.
+var rfc7638Key = Key{
+	KeyType: KeyTypeRSA,
+	N:       "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	E:       "AQAB",
+	//The optional members are not hashed.
+	Algorithm: jwa.RS256Name,
+	KeyID:     "2011-04-29",
+}
+
+func Test_JWK_Thumbprint(t *testing.T) {
+
+	thumbprint, err := rfc7638Key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b64.EncodeURL(thumbprint) != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Unexpected thumbprint: %s", b64.EncodeURL(thumbprint))
+	}
+
+	uri, err := rfc7638Key.ThumbprintURI(crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uri != "urn:ietf:params:oauth:jwk-thumbprint:sha-256:NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Unexpected URI: %s", uri)
+	}
+}
+
+func Test_JWK_ThumbprintPrivate(t *testing.T) {
+
+	for _, alg := range []jwa.Algorithm{jwa.ES256, jwa.EdDSA, jwa.MLDSA44, jwa.PS256} {
+		_, key, err := Generate(alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		private, err := key.Thumbprint(crypto.SHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		public, err := key.Public().Thumbprint(crypto.SHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(private) != string(public) {
+			t.Errorf("%s: the private and public keys must have the same thumbprint", jwa.GetAlgorithmName(alg))
+		}
+	}
+}
+
+func Test_JWK_ThumbprintErrors(t *testing.T) {
+
+	if _, err := (Key{KeyType: KeyTypeEC, Curve: jwa.ECP256Name, X: "AA"}).Thumbprint(crypto.SHA256); !errors.Is(err, ErrMissingMember) {
+		t.Errorf("Expected %s, found %v", ErrMissingMember, err)
+	}
+
+	if _, err := (Key{KeyType: "unknown"}).Thumbprint(crypto.SHA256); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Expected %s, found %v", ErrUnsupportedKey, err)
+	}
+
+	if _, err := rfc7638Key.ThumbprintURI(crypto.MD5); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Expected %s, found %v", ErrUnsupportedKey, err)
+	}
+}
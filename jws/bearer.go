@@ -0,0 +1,370 @@
+package jws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+//Verifier verifies the signature of a token with the keys it knows about,
+//as KeyManager does.
+type Verifier interface {
+	Verify(j *jwt.JWT) error
+}
+
+//VerifierFunc adapts a function to a Verifier, for example to call Verify with fixed Options.
+type VerifierFunc func(j *jwt.JWT) error
+
+//Verify calls f.
+func (f VerifierFunc) Verify(j *jwt.JWT) error {
+	return f(j)
+}
+
+//RequireType returns a Verifier that rejects the tokens whose signatures don't have typ as the
+//"typ" of their protected header before they reach v, with the rules of Options.ExpectedType.
+func RequireType(typ string, v Verifier) Verifier {
+
+	return VerifierFunc(func(j *jwt.JWT) error {
+		if j == nil || len(j.Signatures) == 0 {
+			return ErrSignatureNotFound
+		}
+
+		for _, signature := range j.Signatures {
+			header, err := decodeHeader(signature.Protected)
+			if err != nil {
+				return err
+			}
+			if err = checkType(header, typ); err != nil {
+				return err
+			}
+		}
+		return v.Verify(j)
+	})
+}
+
+//KeySet returns a Verifier that picks the key of the signature by the "kid" of its protected
+//header among the keys returned by keys, such as the JWK Set of an issuer. The keys must have
+//an "alg", and it must be the "alg" of the header, so a token cannot choose how it's verified.
+func KeySet(keys func() jwk.Set) Verifier {
+	return KeyLookup(func(kid string) (*jwk.Key, error) {
+		var set = keys()
+		return set.Lookup(kid)
+	})
+}
+
+//KeyLookup is KeySet with a function that finds a key by its "kid", such as the Lookup method of a
+//jwk.RemoteSet, which refreshes the set when the issuer rotates its keys. A key with no "alg",
+//which RFC 7517 allows, accepts the "alg" of the header only if it's one of algorithms and
+//it fits the "kty" of the key.
+func KeyLookup(lookup func(kid string) (*jwk.Key, error), algorithms ...string) Verifier {
+
+	return VerifierFunc(func(j *jwt.JWT) error {
+		if j == nil {
+			return jwa.ErrInvalidInput
+		}
+
+		var lookupErr error
+		for _, signature := range j.Signatures {
+			header, err := decodeHeader(signature.Protected)
+			if err != nil {
+				continue
+			}
+
+			kid, _ := header["kid"].(string)
+			key, err := lookup(kid)
+			if err != nil {
+				lookupErr = err
+				continue
+			}
+			if key.IsPrivate() {
+				continue
+			}
+
+			name, _ := header["alg"].(string)
+			var alg = jwa.AlgorithmFromName(name)
+			if !keyAllows(key, name, algorithms) {
+				return signatureError(&Options{Algorithm: alg, SignID: kid}, jwa.ErrInvalidAlgorithm)
+			}
+
+			pub, err := key.PublicKey()
+			if err != nil {
+				return err
+			}
+
+			var opt = BlankOptions()
+			opt.Algorithm = alg
+			opt.SignID = kid
+			if err = opt.SetPublicKey(pub); err != nil {
+				return signatureError(opt, err)
+			}
+			return signatureError(opt, verifySignature(j, signature, opt))
+		}
+
+		if lookupErr != nil {
+			return errors.Join(ErrSignatureNotFound, lookupErr)
+		}
+		return ErrSignatureNotFound
+	})
+}
+
+//keyAllows reports whether key can verify a signature whose header has the "alg" name.
+func keyAllows(key *jwk.Key, name string, algorithms []string) bool {
+
+	if key.Algorithm != "" {
+		return key.Algorithm == name
+	}
+
+	if !containsString(algorithms, name) {
+		return false
+	}
+
+	impl, err := jwa.GetImplementation(jwa.AlgorithmFromName(name))
+	return err == nil && impl.KeyType == key.KeyType
+}
+
+type bearerContextKey struct{}
+
+//TokenFromContext returns the token that Bearer verified for the request of ctx.
+func TokenFromContext(ctx context.Context) (*jwt.JWT, bool) {
+	j, ok := ctx.Value(bearerContextKey{}).(*jwt.JWT)
+	return j, ok
+}
+
+//Bearer authenticates HTTP requests with the bearer tokens of RFC 6750: https://tools.ietf.org/html/rfc6750
+//The token is taken from the Authorization header, or from the Cookie or Query parameter
+//if they are set, and a request that uses more than one of them is rejected.
+//The token must be a JWS compact serialization verified by Verifier, with claims that
+//satisfy Validator.
+type Bearer struct {
+	//Verifier checks the signature of the tokens.
+	Verifier Verifier
+	//Type, when not empty, is the "typ" the tokens must have, such as "at+jwt".
+	Type string
+	//Validator checks their claims. "exp" is always required, and the tokens are refused
+	//when Validator.Audience is empty unless AnyAudience is set.
+	Validator jwt.Validator
+	//AnyAudience accepts tokens for any audience when Validator.Audience is empty.
+	AnyAudience bool
+	//Scopes, when not empty, must all be in the "scope" claim: https://tools.ietf.org/html/rfc8693#section-4.2
+	Scopes []string
+
+	//Cookie is the name of the cookie that may carry the token. It's ignored if empty.
+	Cookie string
+	//Query is the name of the query parameter that may carry the token, usually
+	//"access_token": https://tools.ietf.org/html/rfc6750#section-2.3. It's ignored if empty.
+	Query string
+
+	//Realm is sent in the WWW-Authenticate challenges.
+	Realm string
+	//DetailedErrors sends the reason of each failure as the error_description of the
+	//challenges. Otherwise a fixed description of the error code is sent, so the
+	//details of the verification, such as the key lookups, aren't disclosed.
+	DetailedErrors bool
+}
+
+//BearerError is a rejection of Bearer. Its Code is one of the error codes of RFC 6750:
+//https://tools.ietf.org/html/rfc6750#section-3.1
+type BearerError struct {
+	//Code is invalid_request, invalid_token or insufficient_scope, or empty when there's no token.
+	Code string
+	//Err is the reason of the failure.
+	Err error
+}
+
+func (e *BearerError) Error() string {
+	if e.Code == "" {
+		return e.Err.Error()
+	}
+	return e.Code + ": " + e.Err.Error()
+}
+
+//Unwrap returns the reason of the failure.
+func (e *BearerError) Unwrap() error {
+	return e.Err
+}
+
+//bearerDescriptions are the error_description sent for each error code.
+var bearerDescriptions = map[string]string{
+	"invalid_request":    "malformed request",
+	"invalid_token":      "invalid access token",
+	"insufficient_scope": "insufficient scope",
+}
+
+var (
+	//ErrMissingToken means that the request carries no bearer token.
+	ErrMissingToken = errors.New("missing bearer token")
+	//ErrAmbiguousToken means that the request carries the token in more than one way.
+	ErrAmbiguousToken = errors.New("more than one bearer token")
+	//ErrInsufficientScope means that the "scope" claim lacks a required scope.
+	ErrInsufficientScope = errors.New("insufficient scope")
+)
+
+//Authenticate returns the verified token of r, or a *BearerError.
+func (b *Bearer) Authenticate(r *http.Request) (*jwt.JWT, error) {
+
+	raw, err := b.extract(r)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Deserialize([]byte(raw))
+	if err != nil {
+		return nil, &BearerError{Code: "invalid_token", Err: err}
+	}
+
+	if b.Verifier == nil {
+		return nil, &BearerError{Code: "invalid_token", Err: ErrSignatureNotFound}
+	}
+
+	var verifier = b.Verifier
+	if b.Type != "" {
+		verifier = RequireType(b.Type, verifier)
+	}
+
+	if err = verifier.Verify(&token); err != nil {
+		return nil, &BearerError{Code: "invalid_token", Err: err}
+	}
+
+	var validator = b.Validator
+	if validator.Audience == "" && !b.AnyAudience {
+		return nil, &BearerError{Code: "invalid_token", Err: &jwt.ValidationError{Claim: "aud", Err: jwt.ErrInvalidAudience}}
+	}
+	if !containsString(validator.Required, "exp") {
+		validator.Required = append(validator.Required[:len(validator.Required):len(validator.Required)], "exp")
+	}
+
+	if err = validator.Validate(token); err != nil {
+		return nil, &BearerError{Code: "invalid_token", Err: err}
+	}
+
+	if len(b.Scopes) != 0 {
+		scope, _ := token.Payload["scope"].(string)
+		var granted = strings.Fields(scope)
+		for _, s := range b.Scopes {
+			if !containsString(granted, s) {
+				return nil, &BearerError{Code: "insufficient_scope", Err: ErrInsufficientScope}
+			}
+		}
+	}
+	return &token, nil
+}
+
+//extract returns the only token that r carries.
+func (b *Bearer) extract(r *http.Request) (string, error) {
+
+	var found []string
+
+	//Other schemes are treated as a missing token: https://tools.ietf.org/html/rfc6750#section-3.1
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		scheme, token, _ := strings.Cut(authorization, " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			if strings.TrimSpace(token) == "" {
+				return "", &BearerError{Code: "invalid_request", Err: ErrMissingToken}
+			}
+			found = append(found, strings.TrimSpace(token))
+		}
+	}
+
+	if b.Cookie != "" {
+		if cookie, err := r.Cookie(b.Cookie); err == nil && cookie.Value != "" {
+			found = append(found, cookie.Value)
+		}
+	}
+
+	if b.Query != "" {
+		if values, ok := r.URL.Query()[b.Query]; ok {
+			found = append(found, values...)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", &BearerError{Err: ErrMissingToken}
+	case 1:
+		return found[0], nil
+	default:
+		return "", &BearerError{Code: "invalid_request", Err: ErrAmbiguousToken}
+	}
+}
+
+//Middleware calls next with the verified token in the context of the request, see TokenFromContext.
+//The other requests get the status and the WWW-Authenticate challenge of RFC 6750:
+//https://tools.ietf.org/html/rfc6750#section-3
+func (b *Bearer) Middleware(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := b.Authenticate(r)
+		if err != nil {
+			b.challenge(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), bearerContextKey{}, token)))
+	})
+}
+
+//challenge writes the response that rejects a request.
+func (b *Bearer) challenge(w http.ResponseWriter, err error) {
+
+	var code string
+	var bearerErr *BearerError
+	if errors.As(err, &bearerErr) {
+		code = bearerErr.Code
+	}
+
+	var params []string
+	if b.Realm != "" {
+		params = append(params, `realm="`+quotable(b.Realm)+`"`)
+	}
+
+	var status = http.StatusUnauthorized
+	switch code {
+	case "invalid_request":
+		status = http.StatusBadRequest
+	case "insufficient_scope":
+		status = http.StatusForbidden
+	}
+
+	if code != "" {
+		var description = bearerDescriptions[code]
+		if b.DetailedErrors {
+			description = bearerErr.Err.Error()
+		}
+		params = append(params, `error="`+code+`"`, `error_description="`+quotable(description)+`"`)
+	}
+	if code == "insufficient_scope" {
+		params = append(params, `scope="`+quotable(strings.Join(b.Scopes, " "))+`"`)
+	}
+
+	var challenge = "Bearer"
+	if len(params) != 0 {
+		challenge += " " + strings.Join(params, ", ")
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, http.StatusText(status), status)
+}
+
+//quotable removes the characters that cannot be in the quoted values of a challenge:
+//https://tools.ietf.org/html/rfc6750#section-3
+func quotable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
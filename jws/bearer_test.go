@@ -0,0 +1,241 @@
+package jws
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+func bearerToken(t *testing.T, m *KeyManager, claims jwt.Claims) string {
+
+	token := jwt.NewJWT()
+	for k, v := range claims {
+		token.Payload[k] = v
+	}
+	if err := m.Sign(token); err != nil {
+		t.Fatal(err)
+	}
+
+	compact, err := token.CompactSerialization()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(compact)
+}
+
+func Test_Bearer_Middleware(t *testing.T) {
+
+	m := NewKeyManager(jwa.ES256, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var exp = time.Now().Add(time.Minute).Unix()
+	b := &Bearer{
+		Verifier:  KeySet(m.PublicSet),
+		Validator: jwt.Validator{Audience: "api"},
+		Scopes:    []string{"read"},
+		Query:     "access_token",
+		Cookie:    "session",
+		Realm:     "example",
+	}
+
+	handler := b.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := TokenFromContext(r.Context())
+		if !ok {
+			t.Error("Missing token in the context")
+			return
+		}
+		w.Write([]byte(token.Subject()))
+	}))
+
+	valid := bearerToken(t, m, jwt.Claims{"sub": "fido", "aud": "api", "exp": exp, "scope": "read write"})
+	expired := bearerToken(t, m, jwt.Claims{"sub": "fido", "aud": "api", "exp": exp - 120, "scope": "read"})
+	otherAudience := bearerToken(t, m, jwt.Claims{"sub": "fido", "aud": "web", "exp": exp, "scope": "read"})
+	noExpiration := bearerToken(t, m, jwt.Claims{"sub": "fido", "aud": "api", "scope": "read"})
+	noScope := bearerToken(t, m, jwt.Claims{"sub": "fido", "aud": "api", "exp": exp, "scope": "write"})
+	parts := strings.Split(valid, ".")
+	forged := parts[0] + "." + b64.EncodeURL([]byte(`{"sub":"mallory","aud":"api","exp":9999999999,"scope":"read"}`)) + "." + parts[2]
+
+	var tests = []struct {
+		name      string
+		prepare   func(r *http.Request)
+		status    int
+		challenge string
+	}{
+		{"header", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+valid) }, http.StatusOK, ""},
+		{"scheme case", func(r *http.Request) { r.Header.Set("Authorization", "bearer "+valid) }, http.StatusOK, ""},
+		{"cookie", func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "session", Value: valid}) }, http.StatusOK, ""},
+		{"query", func(r *http.Request) { r.URL.RawQuery = "access_token=" + valid }, http.StatusOK, ""},
+		{"missing", func(r *http.Request) {}, http.StatusUnauthorized, `Bearer realm="example"`},
+		{"basic", func(r *http.Request) { r.SetBasicAuth("fido", "pass") }, http.StatusUnauthorized, `Bearer realm="example"`},
+		{"basic and cookie", func(r *http.Request) {
+			r.SetBasicAuth("fido", "pass")
+			r.AddCookie(&http.Cookie{Name: "session", Value: valid})
+		}, http.StatusOK, ""},
+		{"empty", func(r *http.Request) { r.Header.Set("Authorization", "Bearer ") }, http.StatusBadRequest, `error="invalid_request"`},
+		{"two tokens", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+valid)
+			r.URL.RawQuery = "access_token=" + valid
+		}, http.StatusBadRequest, `error="invalid_request"`},
+		{"garbage", func(r *http.Request) { r.Header.Set("Authorization", "Bearer abc") }, http.StatusUnauthorized, `error="invalid_token"`},
+		{"forged", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+forged) }, http.StatusUnauthorized, `error="invalid_token"`},
+		{"expired", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+expired) }, http.StatusUnauthorized, `error="invalid_token"`},
+		{"no expiration", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+noExpiration) }, http.StatusUnauthorized, `error="invalid_token"`},
+		{"audience", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+otherAudience) }, http.StatusUnauthorized, `error="invalid_token"`},
+		{"scope", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+noScope) }, http.StatusForbidden, `error="insufficient_scope"`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		tt.prepare(req)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: Expected %d, found %d", tt.name, tt.status, rec.Code)
+		}
+		if tt.status == http.StatusOK && rec.Body.String() != "fido" {
+			t.Errorf("%s: Unexpected body %s", tt.name, rec.Body.String())
+		}
+		if challenge := rec.Header().Get("WWW-Authenticate"); !strings.Contains(challenge, tt.challenge) {
+			t.Errorf("%s: Unexpected challenge %s", tt.name, challenge)
+		}
+	}
+
+	//A request without a token, or with another scheme, gets no error code: https://tools.ietf.org/html/rfc6750#section-3.1
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("fido", "pass")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if challenge := rec.Header().Get("WWW-Authenticate"); challenge != `Bearer realm="example"` {
+		t.Errorf("Unexpected challenge %s", challenge)
+	}
+
+	//The reasons of the failures are only sent on demand.
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+expired)
+	for _, detailed := range []bool{false, true} {
+		b.DetailedErrors = detailed
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var challenge = rec.Header().Get("WWW-Authenticate")
+		if strings.Contains(challenge, `error_description="invalid access token"`) == detailed {
+			t.Errorf("Unexpected challenge %s", challenge)
+		}
+		if strings.Contains(challenge, jwt.ErrExpired.Error()) != detailed {
+			t.Errorf("Unexpected challenge %s", challenge)
+		}
+	}
+}
+
+func Test_Bearer_KeySetAlgorithm(t *testing.T) {
+
+	m := NewKeyManager(jwa.ES256, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	compact := bearerToken(t, m, jwt.Claims{"sub": "fido"})
+
+	//A key without "alg" doesn't let the token choose one.
+	set := m.PublicSet()
+	set.Keys[0].Algorithm = ""
+	verifier := KeySet(func() jwk.Set { return set })
+
+	token, _ := jwt.Deserialize([]byte(compact))
+	if err := verifier.Verify(&token); !errors.Is(err, jwa.ErrInvalidAlgorithm) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidAlgorithm, err)
+	}
+
+	if err := KeySet(m.PublicSet).Verify(&token); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Bearer_Type(t *testing.T) {
+
+	m := NewKeyManager(jwa.ES256, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Bearer{Verifier: m, Type: jwt.TypeAccessToken, AnyAudience: true}
+	var exp = time.Now().Add(time.Minute).Unix()
+	untyped := bearerToken(t, m, jwt.Claims{"sub": "fido", "exp": exp})
+
+	token := jwt.NewJWT()
+	token.Header["typ"] = jwt.TypeAccessToken
+	token.SetSubject("fido")
+	token.Payload["exp"] = exp
+	m.Sign(token)
+	typed, _ := token.CompactSerialization()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+untyped)
+	if _, err := b.Authenticate(req); !errors.Is(err, jwt.ErrInvalidType) {
+		t.Errorf("Expected %s, found %v", jwt.ErrInvalidType, err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+string(typed))
+	if _, err := b.Authenticate(req); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Bearer_Audience(t *testing.T) {
+
+	m := NewKeyManager(jwa.ES256, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var exp = time.Now().Add(time.Minute).Unix()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+bearerToken(t, m, jwt.Claims{"sub": "fido", "aud": "web", "exp": exp}))
+
+	//Without an audience, the tokens of every other service would be accepted.
+	b := &Bearer{Verifier: m}
+	if _, err := b.Authenticate(req); !errors.Is(err, jwt.ErrInvalidAudience) {
+		t.Errorf("Expected %s, found %v", jwt.ErrInvalidAudience, err)
+	}
+
+	b.AnyAudience = true
+	if _, err := b.Authenticate(req); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Bearer_KeyLookup(t *testing.T) {
+
+	m := NewKeyManager(jwa.EdDSA, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	compact := bearerToken(t, m, jwt.Claims{"sub": "fido"})
+	token, _ := jwt.Deserialize([]byte(compact))
+
+	set := m.PublicSet()
+	set.Keys[0].Algorithm = ""
+	lookup := func(kid string) (*jwk.Key, error) { return set.Lookup(kid) }
+
+	if err := KeyLookup(lookup, jwa.EdDSAName).Verify(&token); err != nil {
+		t.Error(err)
+	}
+	if err := KeyLookup(lookup, jwa.ES256Name).Verify(&token); !errors.Is(err, jwa.ErrInvalidAlgorithm) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidAlgorithm, err)
+	}
+
+	failing := func(string) (*jwk.Key, error) { return nil, jwk.ErrFetchSet }
+	if err := KeyLookup(failing).Verify(&token); !errors.Is(err, ErrSignatureNotFound) || !errors.Is(err, jwk.ErrFetchSet) {
+		t.Errorf("Expected %s and %s, found %v", ErrSignatureNotFound, jwk.ErrFetchSet, err)
+	}
+}
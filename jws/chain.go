@@ -0,0 +1,54 @@
+package jws
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+)
+
+//TrustChain is a TrustPolicy that accepts the leaf key of the "x5c" header parameter
+//once its chain is built and validated with verify: https://tools.ietf.org/html/rfc7515#section-4.1.6
+//
+//verify.Roots must be set, so the system pool is never trusted by accident. The rest of
+//the certificates of "x5c" are added to verify.Intermediates, and the usual checks of
+//x509.Certificate.Verify apply: validity at verify.CurrentTime (now if zero), name
+//constraints of the CAs, extended key usages and verify.DNSName, if given. Unlike
+//x509, an empty verify.KeyUsages accepts any extended key usage instead of serverAuth only.
+//A leaf that restricts its key usage must allow digitalSignature.
+func TrustChain(verify x509.VerifyOptions) TrustPolicy {
+
+	return func(header map[string]interface{}) (crypto.PublicKey, error) {
+		if verify.Roots == nil {
+			return nil, ErrUntrustedChain
+		}
+
+		chain, err := HeaderCertificates(header)
+		if err != nil {
+			return nil, err
+		}
+
+		var opts = verify
+		if opts.Intermediates == nil {
+			opts.Intermediates = x509.NewCertPool()
+		} else {
+			opts.Intermediates = opts.Intermediates.Clone()
+		}
+		for _, cert := range chain[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+
+		if len(opts.KeyUsages) == 0 {
+			opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+		}
+
+		var leaf = chain[0]
+		if leaf.KeyUsage != 0 && leaf.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+			return nil, ErrUntrustedChain
+		}
+
+		if _, err = leaf.Verify(opts); err != nil {
+			return nil, errors.Join(ErrUntrustedChain, err)
+		}
+		return leaf.PublicKey, nil
+	}
+}
@@ -0,0 +1,154 @@
+package jws
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+//issue creates a certificate for a new P-256 key, signed by parent or self-signed if nil.
+func issue(t *testing.T, template *x509.Certificate, parent *testCA) *testCA {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer, signer = template, key
+	if parent != nil {
+		issuer, signer = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func caTemplate(serial int64, name string, permitted ...string) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		PermittedDNSDomains:   permitted,
+	}
+}
+
+func leafTemplate(serial int64, dnsName string, usage x509.KeyUsage) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     usage,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+}
+
+func chainToken(t *testing.T, leaf *testCA, chain ...*x509.Certificate) *jwt.JWT {
+
+	opt := BlankOptions()
+	opt.Algorithm = jwa.ES256
+	opt.SignID = "leaf"
+	if err := opt.SetPrivateKey(leaf.key); err != nil {
+		t.Fatal(err)
+	}
+	opt.Certificates = append([]*x509.Certificate{leaf.cert}, chain...)
+	opt.EmbedCertificates = true
+
+	token := jwt.NewJWT()
+	token.SetIssuer("leaf")
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func Test_Chain_Verify(t *testing.T) {
+
+	root := issue(t, caTemplate(1, "root", "example.com"), nil)
+	intermediate := issue(t, caTemplate(2, "intermediate"), root)
+	leaf := issue(t, leafTemplate(3, "api.example.com", x509.KeyUsageDigitalSignature), intermediate)
+	outside := issue(t, leafTemplate(4, "api.example.org", x509.KeyUsageDigitalSignature), intermediate)
+	encipher := issue(t, leafTemplate(5, "enc.example.com", x509.KeyUsageKeyEncipherment), intermediate)
+	other := issue(t, caTemplate(6, "other"), nil)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(other.cert)
+
+	verifier := BlankOptions()
+	verifier.Algorithm = jwa.ES256
+	verifier.SignID = "leaf"
+
+	var tests = []struct {
+		name   string
+		token  *jwt.JWT
+		verify x509.VerifyOptions
+		err    error
+	}{
+		{"valid", chainToken(t, leaf, intermediate.cert), x509.VerifyOptions{Roots: roots}, nil},
+		{"dns name", chainToken(t, leaf, intermediate.cert), x509.VerifyOptions{Roots: roots, DNSName: "api.example.com"}, nil},
+		{"wrong dns name", chainToken(t, leaf, intermediate.cert), x509.VerifyOptions{Roots: roots, DNSName: "www.example.com"}, ErrUntrustedChain},
+		{"no roots", chainToken(t, leaf, intermediate.cert), x509.VerifyOptions{}, ErrUntrustedChain},
+		{"other root", chainToken(t, leaf, intermediate.cert), x509.VerifyOptions{Roots: otherRoots}, ErrUntrustedChain},
+		{"missing intermediate", chainToken(t, leaf), x509.VerifyOptions{Roots: roots}, ErrUntrustedChain},
+		{"expired", chainToken(t, leaf, intermediate.cert), x509.VerifyOptions{Roots: roots, CurrentTime: time.Now().Add(2 * time.Hour)}, ErrUntrustedChain},
+		{"ext key usage", chainToken(t, leaf, intermediate.cert), x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}}, ErrUntrustedChain},
+		{"name constraints", chainToken(t, outside, intermediate.cert), x509.VerifyOptions{Roots: roots}, ErrUntrustedChain},
+		{"key usage", chainToken(t, encipher, intermediate.cert), x509.VerifyOptions{Roots: roots}, ErrUntrustedChain},
+	}
+
+	for _, tt := range tests {
+		err := VerifyWithPolicy(tt.token, verifier, TrustChain(tt.verify))
+		if tt.err == nil && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: Expected %s, found %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func Test_Chain_AlteredToken(t *testing.T) {
+
+	root := issue(t, caTemplate(1, "root"), nil)
+	leaf := issue(t, leafTemplate(2, "api.example.com", x509.KeyUsageDigitalSignature), root)
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	token := chainToken(t, leaf)
+	token.SetIssuer("mallory")
+
+	verifier := BlankOptions()
+	verifier.Algorithm = jwa.ES256
+	if err := VerifyWithPolicy(token, verifier, TrustChain(x509.VerifyOptions{Roots: roots})); !errors.Is(err, jwa.ErrAlteredMessage) {
+		t.Errorf("Expected %s, found %v", jwa.ErrAlteredMessage, err)
+	}
+}
@@ -0,0 +1,150 @@
+package jws
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	critHeader = `crit`
+	b64Header  = `b64`
+)
+
+//CriticalHandler validates an extension header parameter listed in "crit".
+//It receives the parameter name and the whole JWS Protected Header, and must
+//return a non nil error when the token has to be rejected.
+type CriticalHandler func(name string, header map[string]interface{}) error
+
+var (
+	criticalMu       sync.RWMutex
+	criticalHandlers = map[string]CriticalHandler{
+		b64Header: CriticalB64,
+	}
+)
+
+//The parameters defined by JWS and JWA cannot be listed in "crit".
+var registeredHeaders = map[string]bool{
+	"alg": true, "jku": true, "jwk": true, "kid": true, "x5u": true, "x5c": true,
+	"x5t": true, "x5t#S256": true, "typ": true, "cty": true, critHeader: true,
+}
+
+//RegisterCritical makes the extension header parameter name understood by Verify.
+//Registering a handler for an already registered name replaces it, and a nil handler
+//removes it. The parameters defined by RFC 7515 cannot be registered.
+func RegisterCritical(name string, handler CriticalHandler) error {
+	if name == "" || registeredHeaders[name] {
+		return ErrInvalidCritical
+	}
+
+	criticalMu.Lock()
+	defer criticalMu.Unlock()
+	if handler == nil {
+		delete(criticalHandlers, name)
+	} else {
+		criticalHandlers[name] = handler
+	}
+	return nil
+}
+
+//CriticalB64 handles the "b64" header parameter of the Unencoded Payload Option.
+//It's registered by default. See https://tools.ietf.org/html/rfc7797#section-3
+func CriticalB64(name string, header map[string]interface{}) error {
+	if _, ok := header[name].(bool); !ok {
+		return ErrInvalidCritical
+	}
+	return nil
+}
+
+//CriticalExpiration returns a handler of an "exp" header parameter holding a NumericDate,
+//rejecting the token once that date has passed according to now, or time.Now if nil.
+//Applications that accept such tokens must register it: RegisterCritical("exp", CriticalExpiration(nil))
+func CriticalExpiration(now func() time.Time) CriticalHandler {
+	if now == nil {
+		now = time.Now
+	}
+
+	return func(name string, header map[string]interface{}) error {
+		var exp int64
+		switch v := header[name].(type) {
+		case float64:
+			exp = int64(v)
+		case int64:
+			exp = v
+		case int:
+			exp = int64(v)
+		default:
+			return ErrInvalidCritical
+		}
+
+		if now().Unix() >= exp {
+			return ErrExpiredHeader
+		}
+		return nil
+	}
+}
+
+//checkCritical enforces the rules of https://tools.ietf.org/html/rfc7515#section-4.1.11:
+//"crit" must be a non empty list of names, not defined by the specification,
+//present in the header and understood by a registered CriticalHandler.
+//"b64" must always be listed: https://tools.ietf.org/html/rfc7797#section-6
+func checkCritical(header map[string]interface{}) error {
+
+	_, unencoded := header[b64Header]
+
+	value, ok := header[critHeader]
+	if !ok {
+		if unencoded {
+			return ErrInvalidCritical
+		}
+		return nil
+	}
+
+	var names []string
+	switch list := value.(type) {
+	case []string:
+		names = list
+	case []interface{}:
+		for _, v := range list {
+			name, ok := v.(string)
+			if !ok {
+				return ErrInvalidCritical
+			}
+			names = append(names, name)
+		}
+	default:
+		return ErrInvalidCritical
+	}
+
+	if len(names) == 0 {
+		return ErrInvalidCritical
+	}
+
+	criticalMu.RLock()
+	defer criticalMu.RUnlock()
+
+	var seen = make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" || registeredHeaders[name] || seen[name] {
+			return ErrInvalidCritical
+		}
+		seen[name] = true
+
+		if _, ok := header[name]; !ok {
+			return ErrInvalidCritical
+		}
+
+		handler, ok := criticalHandlers[name]
+		if !ok {
+			return ErrUnknownCritical
+		}
+
+		if err := handler(name, header); err != nil {
+			return err
+		}
+	}
+
+	if unencoded && !seen[b64Header] {
+		return ErrInvalidCritical
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package jws
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+func Test_Crit_UnknownParameter(t *testing.T) {
+	opt := NewOptions(jwa.ES256, testP256Key, testP256PubKey, "crit")
+
+	token := jwt.NewJWT()
+	token.SetIssuer("fido")
+	token.Header["crit"] = []string{"made-up"}
+	token.Header["made-up"] = true
+
+	if err := Sign(token, opt); err == nil {
+		t.Error("missed error")
+	} else if !errors.Is(err, ErrUnknownCritical) {
+		t.Errorf("Expected %s, found %v", ErrUnknownCritical, err)
+	}
+}
+
+func Test_Crit_RegisteredHandler(t *testing.T) {
+	opt := NewOptions(jwa.RS256, testRSAKey, testRSAPubKey, "crit")
+
+	var calls int
+	if err := RegisterCritical("made-up", func(name string, header map[string]interface{}) error {
+		calls++
+		if header[name] != "accepted" {
+			return errors.New("rejected")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer RegisterCritical("made-up", nil)
+
+	token := jwt.NewJWT()
+	token.SetIssuer("fido")
+	token.Header["crit"] = []string{"made-up"}
+	token.Header["made-up"] = "accepted"
+
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(token, opt); err != nil {
+		t.Error(err)
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected the handler to be called %d times, found %d", 2, calls)
+	}
+
+	//Once unregistered, the very same token is not understood anymore.
+	RegisterCritical("made-up", nil)
+	if err := Verify(token, opt); err == nil {
+		t.Error("missed error")
+	} else if !errors.Is(err, ErrUnknownCritical) {
+		t.Errorf("Expected %s, found %v", ErrUnknownCritical, err)
+	}
+}
+
+func Test_Crit_Malformed(t *testing.T) {
+
+	var headers = []map[string]interface{}{
+		{"crit": []interface{}{}},
+		{"crit": "b64", "b64": false},
+		{"crit": []interface{}{"b64"}},
+		{"crit": []interface{}{"alg"}},
+		{"crit": []interface{}{"b64", "b64"}, "b64": false},
+		{"crit": []interface{}{1}},
+	}
+
+	for i, h := range headers {
+		if err := checkCritical(h); err == nil {
+			t.Errorf("%d: missed error", i)
+		} else if !errors.Is(err, ErrInvalidCritical) {
+			t.Errorf("%d: Expected %s, found %v", i, ErrInvalidCritical, err)
+		}
+	}
+
+	if err := RegisterCritical("kid", CriticalB64); err == nil {
+		t.Error("registered parameters cannot be handled as extensions")
+	}
+}
+
+func Test_Crit_UnencodedPayload(t *testing.T) {
+	opt := NewOptions(jwa.ES384, testP384Key, testP384PubKey, "b64")
+
+	token := jwt.NewJWT()
+	token.SetIssuer("fido")
+	token.Header["crit"] = []string{"b64"}
+	token.Header["b64"] = false
+
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(token, opt); err != nil {
+		t.Error(err)
+	}
+
+	compact, err := token.CompactSerialization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := token.Signatures[0].Protected + `.{"iss":"fido"}.`; string(compact[:len(expected)]) != expected {
+		t.Errorf("Expected the payload to be unencoded: %s", compact)
+	}
+}
+
+func Test_Crit_UnlistedB64(t *testing.T) {
+	opt := NewOptions(jwa.ES384, testP384Key, testP384PubKey, "")
+
+	token := jwt.NewJWT()
+	token.SetIssuer("fido")
+	token.Header["b64"] = false
+
+	if err := Sign(token, opt); !errors.Is(err, ErrInvalidCritical) {
+		t.Errorf("Expected %s, found %v", ErrInvalidCritical, err)
+	}
+
+	//The header is refused before the signature is checked.
+	token.Signatures = append(token.Signatures, jwt.Signature{
+		Header:    map[string]interface{}{"alg": "ES384", "b64": false},
+		Protected: b64.EncodeURL([]byte(`{"alg":"ES384","b64":false}`)),
+		Signature: b64.EncodeURL([]byte("signature")),
+	})
+
+	if err := Verify(token, opt); !errors.Is(err, ErrInvalidCritical) {
+		t.Errorf("Expected %s, found %v", ErrInvalidCritical, err)
+	}
+
+	var headers = []map[string]interface{}{
+		{"b64": false},
+		{"b64": true},
+		{"crit": []interface{}{"exp"}, "exp": time.Now().Add(time.Minute).Unix(), "b64": false},
+	}
+	RegisterCritical("exp", CriticalExpiration(nil))
+	defer RegisterCritical("exp", nil)
+	for i, h := range headers {
+		if err := checkCritical(h); !errors.Is(err, ErrInvalidCritical) {
+			t.Errorf("%d: Expected %s, found %v", i, ErrInvalidCritical, err)
+		}
+	}
+}
+
+func Test_Crit_Expiration(t *testing.T) {
+	opt := NewOptions(jwa.ES256, testP256Key, testP256PubKey, "exp")
+
+	var now = time.Now()
+	if err := RegisterCritical("exp", CriticalExpiration(func() time.Time { return now })); err != nil {
+		t.Fatal(err)
+	}
+	defer RegisterCritical("exp", nil)
+
+	token := jwt.NewJWT()
+	token.Header["crit"] = []string{"exp"}
+	token.Header["exp"] = now.Add(time.Minute).Unix()
+
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(token, opt); err != nil {
+		t.Error(err)
+	}
+
+	//The same token, once its header has expired.
+	now = now.Add(2 * time.Minute)
+	if err := Verify(token, opt); !errors.Is(err, ErrExpiredHeader) {
+		t.Errorf("Expected %s, found %v", ErrExpiredHeader, err)
+	}
+
+	token = jwt.NewJWT()
+	token.Header["crit"] = []string{"exp"}
+	token.Header["exp"] = now.Add(-time.Minute).Unix()
+
+	if err := Sign(token, opt); err == nil {
+		t.Error("missed error")
+	} else if !errors.Is(err, ErrExpiredHeader) {
+		t.Errorf("Expected %s, found %v", ErrExpiredHeader, err)
+	}
+}
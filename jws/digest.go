@@ -0,0 +1,92 @@
+package jws
+
+import (
+	"hash"
+
+	"github.com/vegaj/JOSE/jwa"
+)
+
+//newHash returns the hash.Hash the JWS Signing Input must be written to, using key
+//for the algorithms that are keyed, such as the HSXXX MACs.
+//The algorithms that sign the whole message, such as EdDSA, cannot be used with a hash.
+func newHash(opt *Options, key interface{}) (hash.Hash, error) {
+	impl, err := jwa.GetImplementation(opt.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if impl.NewHash == nil {
+		return nil, jwa.ErrInvalidAlgorithm
+	}
+	return impl.NewHash(key)
+}
+
+//signDigest produces the JWS Signature from the sum of the hash returned by newHash.
+func signDigest(digest []byte, opt *Options) ([]byte, error) {
+	impl, err := jwa.GetImplementation(opt.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if impl.SignDigest == nil {
+		return nil, jwa.ErrInvalidAlgorithm
+	}
+
+	key, err := opt.signingKey(impl)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := impl.SignDigest(digest, key)
+	if err != nil {
+		return nil, err
+	}
+	return applyLowS(signature, impl, opt)
+}
+
+//verifyDigest checks the JWS Signature against the sum of the hash returned by newHash.
+func verifyDigest(digest, signature []byte, opt *Options) error {
+	impl, err := jwa.GetImplementation(opt.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	if impl.VerifyDigest == nil {
+		return jwa.ErrInvalidAlgorithm
+	}
+
+	if err = checkLowS(signature, impl, opt); err != nil {
+		return err
+	}
+	return impl.VerifyDigest(digest, signature, opt.Public())
+}
+
+func signMessage(message []byte, opt *Options) ([]byte, error) {
+	impl, err := jwa.GetImplementation(opt.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := opt.signingKey(impl)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := impl.Sign(message, key)
+	if err != nil {
+		return nil, err
+	}
+	return applyLowS(signature, impl, opt)
+}
+
+func verifyMessage(message, signature []byte, opt *Options) error {
+	impl, err := jwa.GetImplementation(opt.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	if err = checkLowS(signature, impl, opt); err != nil {
+		return err
+	}
+	return impl.Verify(message, signature, opt.Public())
+}
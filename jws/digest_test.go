@@ -0,0 +1,83 @@
+package jws
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+//testReverseAlgorithm signs with a MAC that is sent reversed, only to prove that jws
+//dispatches through the jwa registry.
+var testReverseAlgorithm, _ = jwa.RegisterAlgorithm(jwa.Implementation{
+	Name:    "test-reverse",
+	KeyType: `oct`,
+	Hash:    crypto.SHA256,
+	CheckKey: func(key interface{}) error {
+		if _, ok := key.([]byte); !ok {
+			return jwa.ErrInvalidKey
+		}
+		return nil
+	},
+	NewHash: func(key interface{}) (hash.Hash, error) {
+		return hmac.New(sha256.New, key.([]byte)), nil
+	},
+	SignDigest: func(digest []byte, key crypto.PrivateKey) ([]byte, error) {
+		var sig = make([]byte, len(digest))
+		for i := range digest {
+			sig[len(digest)-1-i] = digest[i]
+		}
+		return sig, nil
+	},
+	VerifyDigest: func(digest, signature []byte, key crypto.PublicKey) error {
+		if len(digest) != len(signature) {
+			return jwa.ErrAlteredMessage
+		}
+		for i := range digest {
+			if signature[len(digest)-1-i] != digest[i] {
+				return jwa.ErrAlteredMessage
+			}
+		}
+		return nil
+	},
+})
+
+func Test_JWS_RegisteredAlgorithm(t *testing.T) {
+
+	opt := BlankOptions()
+	opt.Algorithm = testReverseAlgorithm
+	if err := opt.LoadSecret(testMCKey); err != nil {
+		t.Fatal(err)
+	}
+
+	token := jwt.NewJWT()
+	token.SetIssuer("fido")
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	header, _ := decodeHeader(token.Signatures[0].Protected)
+	if header["alg"] != "test-reverse" {
+		t.Errorf("Unexpected alg: %v", header["alg"])
+	}
+
+	if err := Verify(token, opt); err != nil {
+		t.Error(err)
+	}
+
+	var compact bytes.Buffer
+	if err := SignStream(&compact, nil, strings.NewReader(`{"iss":"fido"}`), opt); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyStream(&compact, io.Discard, opt); err != nil {
+		t.Error(err)
+	}
+}
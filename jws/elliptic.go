@@ -2,8 +2,6 @@ package jws
 
 import (
 	"crypto/ecdsa"
-	"errors"
-	"math/big"
 
 	"github.com/vegaj/JOSE/jwa"
 )
@@ -11,134 +9,90 @@ import (
 //EllipticSign will perform the signature of message with the given options
 func EllipticSign(message []byte, opt *Options) (signature []byte, err error) {
 
-	r, s, err := jwa.EllipticSign(message, opt.Private().(*ecdsa.PrivateKey), opt.Algorithm)
+	impl, err := jwa.GetImplementation(opt.Algorithm)
 	if err != nil {
 		return nil, err
 	}
 
-	//The err field will be nil because that check is already performed on jwa.EllipticSign
-	signature, err = allocSignature(opt.Algorithm)
+	key, err := opt.signingKey(impl)
 	if err != nil {
 		return nil, err
 	}
 
-	//In this case, we can trust cap(signature) / 2 because we fixed it in allocSignature.
+	r, s, err := jwa.EllipticSign(message, key, opt.Algorithm)
+	if err != nil {
+		return nil, err
+	}
 
-	var space = len(signature) / 2
-	var lower = addPadding(r.Bytes(), space)
-	var upper = addPadding(s.Bytes(), space)
-	//offsetWrite(signature, lower, 0)
-	//offsetWrite(signature, upper, space)
-	signature = append(lower, upper...)
-	if len(signature) != jwa.ESP256Octets && len(signature) != jwa.ESP384Octets && len(signature) != jwa.ESP521Octets {
-		panic(len(signature))
+	signature, err = jwa.EncodeEllipticSignature(r, s, opt.Algorithm)
+	if err != nil {
+		return nil, err
 	}
-	return signature, nil
+	return applyLowS(signature, impl, opt)
 }
 
 //EllipticVerify will verify that message with signed with options produces the signature
 func EllipticVerify(message, signature []byte, opt *Options) (err error) {
-	r, s := big.NewInt(0), big.NewInt(0)
-
-	var space = octetsLength(opt.Algorithm)
-	if space < 0 {
-		return errors.New(jwa.ErrInvalidAlgorithm)
-	}
-
-	bytesR := removePadding(signature[:space])
-	bytesS := removePadding(signature[space:])
-
-	r = r.SetBytes(bytesR)
-	s = s.SetBytes(bytesS)
-
-	err = jwa.EllipticVerify(message, opt.Public().(*ecdsa.PublicKey), r, s, opt.Algorithm)
-	return err
-
-}
-
-func offsetWrite(dst, p []byte, offset int) (n int, err error) {
-
-	var space = cap(dst) - offset
-	var toWrite = space
 
-	if p == nil {
-		return 0, errors.New(jwa.ErrInvalidSource)
-	}
-
-	if space == 0 {
-		return 0, errors.New(jwa.ErrNoSpace)
+	r, s, err := jwa.DecodeEllipticSignature(signature, opt.Algorithm)
+	if err != nil {
+		return err
 	}
 
-	if len(p) < space {
-		toWrite = len(p)
+	impl, err := jwa.GetImplementation(opt.Algorithm)
+	if err != nil {
+		return err
 	}
 
-	var i int
-	for i = 0; i < toWrite; i++ {
-		dst[offset+i] = p[i]
+	if err = checkLowS(signature, impl, opt); err != nil {
+		return err
 	}
 
-	return i, nil
+	return jwa.EllipticVerify(message, opt.Public(), r, s, opt.Algorithm)
 }
 
-func allocSignature(alg jwa.Algorithm) ([]byte, error) {
-	switch alg {
-	case jwa.ES256:
-		return make([]byte, jwa.ESP256Octets), nil
-	case jwa.ES384:
-		return make([]byte, jwa.ESP384Octets), nil
-	case jwa.ES512:
-		return make([]byte, jwa.ESP521Octets), nil
-	default:
-		return nil, errors.New(jwa.ErrInvalidAlgorithm)
-	}
-}
+//applyLowS normalizes the S of an ECDSA JWS Signature when opt.LowS is set.
+func applyLowS(signature []byte, impl jwa.Implementation, opt *Options) ([]byte, error) {
 
-func octetsLength(alg jwa.Algorithm) int {
-	switch alg {
-	case jwa.ES256:
-		return jwa.ESP256Octets / 2
-	case jwa.ES384:
-		return jwa.ESP384Octets / 2
-	case jwa.ES512:
-		return jwa.ESP521Octets / 2
-	default:
-		return -1
+	if !opt.LowS || impl.KeyType != `EC` {
+		return signature, nil
 	}
-}
 
-func addPadding(src []byte, capacity int) []byte {
+	pub, ok := opt.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, jwa.ErrInvalidKey
+	}
 
-	data := make([]byte, capacity, capacity)
-	for i := 0; i < capacity; i++ {
-		if i < len(src) {
-			data[i] = src[i]
-		} else {
-			data[i] = 0
-		}
+	r, s, err := jwa.DecodeEllipticSignature(signature, opt.Algorithm)
+	if err != nil {
+		return nil, err
 	}
 
-	return data
+	if jwa.IsLowS(s, pub.Curve) {
+		return signature, nil
+	}
+	return jwa.EncodeEllipticSignature(r, jwa.NormalizeLowS(s, pub.Curve), opt.Algorithm)
 }
 
-func removePadding(p []byte) []byte {
+//checkLowS rejects the ECDSA JWS Signatures with a high S when opt.LowS is set.
+func checkLowS(signature []byte, impl jwa.Implementation, opt *Options) error {
 
-	if p == nil {
+	if !opt.LowS || impl.KeyType != `EC` {
 		return nil
 	}
 
-	if len(p) == 0 {
-		return p
+	pub, ok := opt.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return jwa.ErrInvalidKey
 	}
 
-	index := len(p)
-
-	for i := 0; i < len(p); i++ {
-		if p[len(p)-1-i] != 0 {
-			return p[:index]
-		}
-		index--
+	_, s, err := jwa.DecodeEllipticSignature(signature, opt.Algorithm)
+	if err != nil {
+		return err
 	}
 
-	return p
+	if !jwa.IsLowS(s, pub.Curve) {
+		return jwa.ErrMalformedSignature
+	}
+	return nil
 }
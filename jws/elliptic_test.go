@@ -1,50 +1,14 @@
 package jws
 
 import (
-	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
 	"testing"
 
 	"github.com/vegaj/JOSE/jwa"
 )
 
-func Test_RemoveTrailing(t *testing.T) {
-
-	allZeroes := []byte{0, 0, 0, 0, 0, 0, 0, 0}
-	if r := removePadding(allZeroes); len(r) != 8 || !bytes.Equal(allZeroes, r) {
-		t.Error("failure with allZeroes")
-	}
-
-	oneTrail := []byte{1, 2, 0, 0, 1, 0}
-	if r := removePadding(oneTrail); len(r) != len(oneTrail)-1 {
-		t.Error("failure with oneTrail")
-	}
-
-	blank := []byte(nil)
-	if r := removePadding(blank); r != nil {
-		t.Error("failure with blank:", blank)
-	}
-
-	noZeroes := []byte{1, 2, 3, 4, 5}
-	if r := removePadding(noZeroes); !bytes.Equal(r, noZeroes) {
-		t.Error("Failure with noZeroes")
-	}
-
-	oneZero := []byte{0}
-	if r := removePadding(oneZero); !bytes.Equal(oneZero, r) {
-		t.Error("Failure with oneZero")
-	}
-
-	zeroElems := []byte{}
-	if r := removePadding(zeroElems); len(r) != 0 {
-		t.Error("Failure on zeroElems")
-	}
-
-	allZeroesButTheLastOne := []byte{0, 0, 0, 0, 0, 0, 0, 0, 1}
-	if r := removePadding(allZeroesButTheLastOne); !bytes.Equal(r, allZeroesButTheLastOne) {
-		t.Error("Failure on allZeroesButTheLastOne")
-	}
-}
-
 func Test_ECS_SignVerification(t *testing.T) {
 
 	var opt = NewOptions(jwa.ES256, testP256Key, testP256PubKey, "my-id")
@@ -123,7 +87,7 @@ func Test_ECS_AlgAndKeyDontMatch(t *testing.T) {
 	_, err := EllipticSign(message, opt)
 	if err == nil {
 		t.Errorf("Error not detected")
-	} else if err.Error() != jwa.ErrInvalidCurve {
+	} else if !errors.Is(err, jwa.ErrInvalidCurve) {
 		t.Errorf("Expected error: %s, found %v", jwa.ErrInvalidCurve, err)
 	}
 }
@@ -148,7 +112,7 @@ func Test_ECS_VerifyInvalidAlg(t *testing.T) {
 	err = EllipticVerify(message, sig, opt)
 	if err == nil {
 		t.Error("Error undetected")
-	} else if err.Error() != jwa.ErrInvalidAlgorithm {
+	} else if !errors.Is(err, jwa.ErrInvalidAlgorithm) {
 		t.Errorf("Expected %s, found  %v", jwa.ErrInvalidAlgorithm, err)
 	}
 
@@ -206,3 +170,88 @@ func Test_ECS_BadAlgGoodKeys(t *testing.T) {
 		t.Errorf("opt expected to be nil")
 	}
 }
+
+func Test_ECS_LowS(t *testing.T) {
+
+	var opt = NewOptions(jwa.ES256, testP256Key, testP256PubKey, "low-s")
+	opt.LowS = true
+
+	var message = []byte("this is my message")
+	var curve = opt.Public().(*ecdsa.PublicKey).Curve
+	for i := 0; i < 8; i++ {
+		sig, err := EllipticSign(message, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, s, err := jwa.DecodeEllipticSignature(sig, opt.Algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !jwa.IsLowS(s, curve) {
+			t.Fatalf("High S: %x", s)
+		}
+
+		if err = EllipticVerify(message, sig, opt); err != nil {
+			t.Fatal(err)
+		}
+
+		//The twin signature is valid, but only accepted without LowS.
+		twin, err := jwa.EncodeEllipticSignature(r, new(big.Int).Sub(curve.Params().N, s), opt.Algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = EllipticVerify(message, twin, opt); !errors.Is(err, jwa.ErrMalformedSignature) {
+			t.Errorf("Expected %s, found %v", jwa.ErrMalformedSignature, err)
+		}
+
+		opt.LowS = false
+		if err = EllipticVerify(message, twin, opt); err != nil {
+			t.Error(err)
+		}
+		opt.LowS = true
+	}
+}
+
+//Malformed signatures must never make the verification panic.
+func FuzzECS_Verify(f *testing.F) {
+
+	var opts = map[jwa.Algorithm]*Options{
+		jwa.ES256: NewOptions(jwa.ES256, testP256Key, testP256PubKey, "es256"),
+		jwa.ES384: NewOptions(jwa.ES384, testP384Key, testP384PubKey, "es384"),
+		jwa.ES512: NewOptions(jwa.ES512, testP521Key, testP521PubKey, "es512"),
+	}
+	opts[jwa.ES384].LowS = true
+
+	var message = []byte("this is my message")
+	for alg, opt := range opts {
+		sig, err := EllipticSign(message, opt)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(sig, uint8(alg))
+		f.Add(sig[:len(sig)/2], uint8(alg))
+		f.Add(append(sig, 0), uint8(alg))
+	}
+	f.Add([]byte{}, uint8(jwa.ES256))
+	f.Add(make([]byte, jwa.ESP256Octets), uint8(jwa.ES256))
+
+	f.Fuzz(func(t *testing.T, signature []byte, code uint8) {
+
+		var opt, ok = opts[jwa.Algorithm(code)]
+		if !ok {
+			opt = opts[jwa.ES256]
+		}
+
+		if err := EllipticVerify(message, signature, opt); err != nil {
+			return
+		}
+
+		//Only the signatures of message can be valid.
+		if err := EllipticVerify([]byte("another message"), signature, opt); err == nil {
+			t.Fatalf("Accepted for two messages: %x", signature)
+		}
+	})
+}
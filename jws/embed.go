@@ -0,0 +1,206 @@
+package jws
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+const (
+	jwkHeader     = `jwk`
+	x5cHeader     = `x5c`
+	x5tHeader     = `x5t`
+	x5tS256Header = `x5t#S256`
+)
+
+//TrustPolicy decides whether the signer described by a JWS Protected Header can be trusted,
+//and returns the public key that must verify the signature. It's used by VerifyWithPolicy,
+//and it must return a non nil error to reject the token.
+type TrustPolicy func(header map[string]interface{}) (crypto.PublicKey, error)
+
+//embedSigner adds to the protected header the key and certificates of opt that must be embedded.
+func embedSigner(protected map[string]interface{}, opt *Options) error {
+
+	var pub = opt.Public()
+	if _, ok := pub.([]byte); ok && (opt.EmbedKey || len(opt.Certificates) != 0) {
+		//A secret cannot be published.
+		return jwa.ErrInvalidKey
+	}
+
+	if opt.EmbedKey {
+		key, err := jwk.FromKey(pub)
+		if err != nil {
+			return err
+		}
+		protected[jwkHeader] = key.Public()
+	}
+
+	if len(opt.Certificates) == 0 {
+		return nil
+	}
+
+	var leaf = opt.Certificates[0]
+	if k, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(pub) {
+		return jwa.ErrInvalidKey
+	}
+
+	if opt.EmbedCertificates {
+		//The certificates are base64 encoded, not base64url: https://tools.ietf.org/html/rfc7515#section-4.1.6
+		var chain = make([]string, len(opt.Certificates))
+		for i, cert := range opt.Certificates {
+			chain[i] = base64.StdEncoding.EncodeToString(cert.Raw)
+		}
+		protected[x5cHeader] = chain
+	}
+
+	if opt.EmbedThumbprints {
+		sum1 := sha1.Sum(leaf.Raw)
+		sum256 := sha256.Sum256(leaf.Raw)
+		protected[x5tHeader] = b64.EncodeURL(sum1[:])
+		protected[x5tS256Header] = b64.EncodeURL(sum256[:])
+	}
+	return nil
+}
+
+//HeaderKey returns the public key of the "jwk" parameter of a JWS Protected Header.
+//See https://tools.ietf.org/html/rfc7515#section-4.1.3
+func HeaderKey(header map[string]interface{}) (crypto.PublicKey, error) {
+
+	value, ok := header[jwkHeader]
+	if !ok {
+		return nil, ErrHeaderNotFound
+	}
+
+	//The header was decoded as a generic JSON object.
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	var key jwk.Key
+	if err = json.Unmarshal(raw, &key); err != nil || key.IsPrivate() {
+		return nil, ErrInvalidHeader
+	}
+
+	pub, err := key.PublicKey()
+	if err != nil {
+		return nil, ErrInvalidHeader
+	}
+	return pub, nil
+}
+
+//HeaderCertificates returns the certificate chain of the "x5c" parameter of a JWS Protected Header,
+//leaf first: https://tools.ietf.org/html/rfc7515#section-4.1.6
+//If the "x5t" or "x5t#S256" thumbprints are present too, they must match the leaf.
+//The chain is not validated.
+func HeaderCertificates(header map[string]interface{}) ([]*x509.Certificate, error) {
+
+	value, ok := header[x5cHeader]
+	if !ok {
+		return nil, ErrHeaderNotFound
+	}
+
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, ErrInvalidHeader
+	}
+
+	var chain = make([]*x509.Certificate, len(list))
+	for i, v := range list {
+		encoded, ok := v.(string)
+		if !ok {
+			return nil, ErrInvalidHeader
+		}
+
+		der, err := base64.StdEncoding.Strict().DecodeString(encoded)
+		if err != nil {
+			return nil, ErrInvalidHeader
+		}
+
+		if chain[i], err = x509.ParseCertificate(der); err != nil {
+			return nil, ErrInvalidHeader
+		}
+	}
+
+	sum1 := sha1.Sum(chain[0].Raw)
+	sum256 := sha256.Sum256(chain[0].Raw)
+	for name, sum := range map[string][]byte{x5tHeader: sum1[:], x5tS256Header: sum256[:]} {
+		if thumbprint, ok := header[name]; ok && thumbprint != b64.EncodeURL(sum) {
+			return nil, ErrInvalidHeader
+		}
+	}
+	return chain, nil
+}
+
+//TrustThumbprints is a TrustPolicy that accepts the keys of the "jwk" header parameter
+//whose RFC 7638 SHA-256 thumbprint, base64url encoded, is one of thumbprints.
+func TrustThumbprints(thumbprints ...string) TrustPolicy {
+
+	var trusted = make(map[string]bool, len(thumbprints))
+	for _, t := range thumbprints {
+		trusted[t] = true
+	}
+
+	return func(header map[string]interface{}) (crypto.PublicKey, error) {
+		pub, err := HeaderKey(header)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := jwk.FromKey(pub)
+		if err != nil {
+			return nil, err
+		}
+
+		thumbprint, err := key.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+
+		if !trusted[b64.EncodeURL(thumbprint)] {
+			return nil, ErrUntrustedKey
+		}
+		return pub, nil
+	}
+}
+
+//VerifyWithPolicy is Verify with the key that policy returns for the JWS Protected Header of
+//the signature, such as the one of the "jwk" or "x5c" parameters. Only the Algorithm, SignID
+//and verification settings of opt are used; the key must match opt.Algorithm.
+func VerifyWithPolicy(j *jwt.JWT, opt *Options, policy TrustPolicy) error {
+
+	if j == nil || opt == nil || policy == nil {
+		return jwa.ErrInvalidInput
+	}
+
+	signature, err := findTargetSignature(j.Signatures, opt)
+	if err != nil {
+		return err
+	}
+
+	header, err := decodeHeader(signature.Protected)
+	if err != nil {
+		return signatureError(opt, err)
+	}
+
+	pub, err := policy(header)
+	if err != nil {
+		return signatureError(opt, err)
+	}
+
+	var trusted = *opt
+	trusted.keySet = digSign{}
+	if err = trusted.SetPublicKey(pub); err != nil {
+		return signatureError(opt, err)
+	}
+
+	return signatureError(opt, verifySignature(j, signature, &trusted))
+}
@@ -0,0 +1,145 @@
+package jws
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+func embedOptions(t *testing.T) (*Options, *ecdsa.PrivateKey, *x509.Certificate) {
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(selfSignedCertificate(t, priv))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opt := BlankOptions()
+	opt.Algorithm = jwa.ES256
+	opt.SignID = "fido"
+	if err = opt.SetPrivateKey(priv); err != nil {
+		t.Fatal(err)
+	}
+	return opt, priv, cert
+}
+
+func Test_Embed_Key(t *testing.T) {
+
+	opt, priv, _ := embedOptions(t)
+	opt.EmbedKey = true
+
+	token := jwt.NewJWT()
+	token.SetIssuer("fido")
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	header, _ := decodeHeader(token.Signatures[0].Protected)
+	pub, err := HeaderKey(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priv.PublicKey.Equal(pub) {
+		t.Errorf("Unexpected key in the header: %v", header["jwk"])
+	}
+
+	k, _ := jwk.FromKey(&priv.PublicKey)
+	thumbprint, _ := k.Thumbprint(crypto.SHA256)
+
+	verifier := BlankOptions()
+	verifier.Algorithm = jwa.ES256
+	if err = VerifyWithPolicy(token, verifier, TrustThumbprints(b64.EncodeURL(thumbprint))); err != nil {
+		t.Error(err)
+	}
+
+	if err = VerifyWithPolicy(token, verifier, TrustThumbprints("other")); !errors.Is(err, ErrUntrustedKey) {
+		t.Errorf("Expected %s, found %v", ErrUntrustedKey, err)
+	}
+
+	//A policy cannot make a key of another type pass for a HMAC secret.
+	verifier.Algorithm = jwa.HS256
+	if err = VerifyWithPolicy(token, verifier, TrustThumbprints(b64.EncodeURL(thumbprint))); err == nil {
+		t.Error("Expected the EC key to be refused for HS256")
+	}
+}
+
+func Test_Embed_PrivateKey(t *testing.T) {
+
+	_, priv, _ := embedOptions(t)
+	k, _ := jwk.FromKey(priv)
+
+	header := map[string]interface{}{"jwk": k}
+	if _, err := HeaderKey(header); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("Expected %s, found %v", ErrInvalidHeader, err)
+	}
+
+	if _, err := HeaderKey(map[string]interface{}{}); !errors.Is(err, ErrHeaderNotFound) {
+		t.Errorf("Expected %s, found %v", ErrHeaderNotFound, err)
+	}
+}
+
+func Test_Embed_Certificates(t *testing.T) {
+
+	opt, _, cert := embedOptions(t)
+	opt.Certificates = []*x509.Certificate{cert}
+	opt.EmbedCertificates = true
+	opt.EmbedThumbprints = true
+
+	token := jwt.NewJWT()
+	token.SetIssuer("fido")
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	header, _ := decodeHeader(token.Signatures[0].Protected)
+	chain, err := HeaderCertificates(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 1 || !chain[0].Equal(cert) {
+		t.Errorf("Unexpected chain: %v", header["x5c"])
+	}
+
+	header["x5t#S256"] = b64.EncodeURL(make([]byte, 32))
+	if _, err = HeaderCertificates(header); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("Expected %s, found %v", ErrInvalidHeader, err)
+	}
+
+	header["x5c"] = []interface{}{"not a certificate"}
+	if _, err = HeaderCertificates(header); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("Expected %s, found %v", ErrInvalidHeader, err)
+	}
+}
+
+func Test_Embed_WrongCertificate(t *testing.T) {
+
+	opt, _, _ := embedOptions(t)
+	_, _, other := embedOptions(t)
+	opt.Certificates = []*x509.Certificate{other}
+	opt.EmbedCertificates = true
+
+	if err := Sign(jwt.NewJWT(), opt); !errors.Is(err, jwa.ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidKey, err)
+	}
+
+	secret := BlankOptions()
+	secret.Algorithm = jwa.HS256
+	secret.LoadSecret(testMCKey)
+	secret.EmbedKey = true
+	if err := Sign(jwt.NewJWT(), secret); !errors.Is(err, jwa.ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidKey, err)
+	}
+}
@@ -0,0 +1,56 @@
+package jws
+
+import (
+	"errors"
+
+	"github.com/vegaj/JOSE/jwa"
+)
+
+var (
+	//ErrSignatureNotFound means that the signature with a certain kid is missing.
+	ErrSignatureNotFound = errors.New("signature not found")
+	//ErrHeaderNotFound means that the signature was expected to have a header, which was found to be malformed or not present.
+	ErrHeaderNotFound = errors.New("header not found")
+	//ErrUnknownCritical means that the "crit" header lists a parameter with no registered handler.
+	ErrUnknownCritical = errors.New("unknown critical header parameter")
+	//ErrInvalidCritical means that the "crit" header is malformed: https://tools.ietf.org/html/rfc7515#section-4.1.11
+	ErrInvalidCritical = errors.New("invalid crit header")
+	//ErrExpiredHeader means that the "exp" header parameter is in the past.
+	ErrExpiredHeader = errors.New("expired header")
+	//ErrInvalidHeader means that a header parameter has a malformed or inconsistent value.
+	ErrInvalidHeader = errors.New("invalid header parameter")
+	//ErrUntrustedKey means that the key found in a header was rejected by a TrustPolicy.
+	ErrUntrustedKey = errors.New("untrusted key")
+	//ErrUntrustedChain means that the "x5c" certificates do not chain up to a trusted root.
+	ErrUntrustedChain = errors.New("untrusted certificate chain")
+	//ErrMalformedStream means that the compact serialization read from a stream is not a valid JWS.
+	ErrMalformedStream = errors.New("malformed JWS stream")
+)
+
+//SignatureError is returned when a signature cannot be verified.
+//It identifies the signature by its key ID and algorithm, and wraps the cause,
+//so errors.Is(err, jwa.ErrAlteredMessage) still reports a forged token.
+type SignatureError struct {
+	//KeyID is the "kid" of the signature.
+	KeyID string
+	//Algorithm is the "alg" the signature was expected to use.
+	Algorithm string
+	//Err is the reason of the failure.
+	Err error
+}
+
+func (e *SignatureError) Error() string {
+	return "signature " + e.KeyID + " (" + e.Algorithm + "): " + e.Err.Error()
+}
+
+//Unwrap returns the reason of the failure.
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+func signatureError(opt *Options, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &SignatureError{KeyID: opt.SignID, Algorithm: jwa.GetAlgorithmName(opt.Algorithm), Err: err}
+}
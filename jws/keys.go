@@ -0,0 +1,130 @@
+package jws
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/vegaj/JOSE/jwa"
+)
+
+//parsePrivateKey accepts a PEM or DER encoded private key in the PKCS#8, PKCS#1 (RSA) or SEC 1 (EC) forms.
+//Ed25519 and ML-DSA keys are only found in the PKCS#8 form.
+func parsePrivateKey(data []byte) (crypto.PrivateKey, error) {
+
+	der, pemType, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pemType {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(der)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(der)
+	case "":
+		//Plain DER, try every form.
+		if k, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+			return k, nil
+		}
+		if k, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+			return k, nil
+		}
+		if k, err := x509.ParseECPrivateKey(der); err == nil {
+			return k, nil
+		}
+	}
+	return nil, jwa.ErrInvalidKey
+}
+
+//parsePublicKey accepts a PEM or DER encoded public key in the PKIX (SubjectPublicKeyInfo) or PKCS#1 (RSA) forms,
+//or the public key of a X.509 certificate.
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+
+	der, pemType, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pemType {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(der)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(der)
+	case "CERTIFICATE":
+		return parseCertificateKey(der)
+	case "":
+		//Plain DER, try every form.
+		if k, err := x509.ParsePKIXPublicKey(der); err == nil {
+			return k, nil
+		}
+		if k, err := x509.ParsePKCS1PublicKey(der); err == nil {
+			return k, nil
+		}
+		if k, err := parseCertificateKey(der); err == nil {
+			return k, nil
+		}
+	}
+	return nil, jwa.ErrInvalidKey
+}
+
+func parseCertificateKey(der []byte) (crypto.PublicKey, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return cert.PublicKey, nil
+}
+
+//decodePEM returns the content and type of the first PEM block that holds a key or a certificate.
+//If data is not PEM encoded, it's returned as is with an empty type.
+func decodePEM(data []byte) ([]byte, string, error) {
+
+	if len(data) == 0 {
+		return nil, "", jwa.ErrInvalidKey
+	}
+
+	var rest = data
+	var found bool
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		found = true
+
+		//openssl ecparam -genkey writes the curve parameters before the key.
+		if block.Type == "EC PARAMETERS" {
+			continue
+		}
+
+		//Encrypted legacy PEM blocks are not supported.
+		if _, ok := block.Headers["Proc-Type"]; ok {
+			return nil, "", jwa.ErrInvalidKey
+		}
+		return block.Bytes, block.Type, nil
+	}
+
+	if found {
+		return nil, "", jwa.ErrInvalidKey
+	}
+	return data, "", nil
+}
+
+//checkKeyAlgorithm ensures that key is of the type used by alg, as told by its jwa.Implementation.
+//The elliptic curves are checked on signature and verification.
+func checkKeyAlgorithm(key interface{}, alg jwa.Algorithm) error {
+
+	impl, err := jwa.GetImplementation(alg)
+	if err != nil {
+		return err
+	}
+
+	if impl.CheckKey == nil {
+		return nil
+	}
+	return impl.CheckKey(key)
+}
@@ -0,0 +1,237 @@
+package jws
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/mldsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+func pemEncode(kind string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+}
+
+func selfSignedCertificate(t *testing.T, priv *ecdsa.PrivateKey) []byte {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fido"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func Test_Keys_Formats(t *testing.T) {
+
+	rsaKey, _ := x509.ParsePKCS1PrivateKey(testRSAKey)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaPKCS8, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+	rsaPKIX, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	ecPKCS8, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+	ecSEC1, _ := x509.MarshalECPrivateKey(ecKey)
+	ecPKIX, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	ecParams := pemEncode("EC PARAMETERS", []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07})
+	cert := selfSignedCertificate(t, ecKey)
+
+	mlKey, err := mldsa.GenerateKey(mldsa.MLDSA44())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mlPKCS8, _ := x509.MarshalPKCS8PrivateKey(mlKey)
+	mlPKIX, _ := x509.MarshalPKIXPublicKey(mlKey.PublicKey())
+
+	var cases = []struct {
+		name      string
+		alg       jwa.Algorithm
+		priv, pub []byte
+	}{
+		{"RSA PKCS#1 DER", jwa.RS256, testRSAKey, testRSAPubKey},
+		{"RSA PKCS#1 PEM", jwa.PS256, pemEncode("RSA PRIVATE KEY", testRSAKey), pemEncode("RSA PUBLIC KEY", testRSAPubKey)},
+		{"RSA PKCS#8 DER", jwa.RS384, rsaPKCS8, rsaPKIX},
+		{"RSA PKCS#8 PEM", jwa.RS512, pemEncode("PRIVATE KEY", rsaPKCS8), pemEncode("PUBLIC KEY", rsaPKIX)},
+		{"EC SEC 1 DER", jwa.ES256, ecSEC1, ecPKIX},
+		{"EC SEC 1 PEM", jwa.ES256, append(ecParams, pemEncode("EC PRIVATE KEY", ecSEC1)...), pemEncode("PUBLIC KEY", ecPKIX)},
+		{"EC PKCS#8 PEM", jwa.ES256, pemEncode("PRIVATE KEY", ecPKCS8), pemEncode("CERTIFICATE", cert)},
+		{"EC certificate DER", jwa.ES256, ecPKCS8, cert},
+		{"ML-DSA PKCS#8 PEM", jwa.MLDSA44, pemEncode("PRIVATE KEY", mlPKCS8), pemEncode("PUBLIC KEY", mlPKIX)},
+	}
+
+	for _, c := range cases {
+		opt := NewOptions(c.alg, c.priv, c.pub, c.name)
+		if opt == nil {
+			t.Errorf("%s: cannot be loaded", c.name)
+			continue
+		}
+
+		token := jwt.NewJWT()
+		token.SetIssuer("fido")
+		if err := Sign(token, opt); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+
+		if err := Verify(token, opt); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+	}
+}
+
+func Test_Keys_PublicFromPrivate(t *testing.T) {
+	opt := BlankOptions()
+	opt.Algorithm = jwa.ES384
+	if err := opt.LoadPrivateKey(testP384Key); err != nil {
+		t.Fatal(err)
+	}
+
+	token := jwt.NewJWT()
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(token, opt); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Keys_AlgorithmMismatch(t *testing.T) {
+
+	opt := BlankOptions()
+	opt.Algorithm = jwa.RS256
+	if err := opt.LoadPrivateKey(pemEncode("EC PRIVATE KEY", testP256Key)); !errors.Is(err, jwa.ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidKey, err)
+	}
+
+	opt.Algorithm = jwa.ES256
+	if err := opt.LoadPublicKey(testRSAPubKey); !errors.Is(err, jwa.ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidKey, err)
+	}
+
+	opt.Algorithm = jwa.HS256
+	if err := opt.LoadPrivateKey(testP256Key); !errors.Is(err, jwa.ErrInvalidAlgorithm) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidAlgorithm, err)
+	}
+}
+
+func Test_Keys_Malformed(t *testing.T) {
+
+	encrypted := pem.EncodeToMemory(&pem.Block{
+		Type:    "RSA PRIVATE KEY",
+		Headers: map[string]string{"Proc-Type": "4,ENCRYPTED", "DEK-Info": "AES-256-CBC,00"},
+		Bytes:   testRSAKey,
+	})
+
+	var inputs = [][]byte{
+		nil,
+		[]byte("not a key"),
+		encrypted,
+		pemEncode("ENCRYPTED PRIVATE KEY", testRSAKey),
+		pemEncode("EC PARAMETERS", []byte{0x06}),
+	}
+
+	for i, in := range inputs {
+		opt := BlankOptions()
+		opt.Algorithm = jwa.RS256
+		if err := opt.LoadPrivateKey(in); err == nil {
+			t.Errorf("%d: missed error", i)
+		}
+	}
+}
+
+//opaqueSigner hides the concrete key type, as the adapters of a HSM or a KMS do.
+type opaqueSigner struct {
+	signer crypto.Signer
+}
+
+func (s opaqueSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+func (s opaqueSigner) Sign(r io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.signer.Sign(r, digest, opts)
+}
+
+func Test_Keys_Signer(t *testing.T) {
+
+	for _, alg := range []jwa.Algorithm{jwa.RS256, jwa.PS384, jwa.ES256, jwa.ES512, jwa.EdDSA} {
+		key, _, err := jwk.Generate(alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opt := BlankOptions()
+		opt.Algorithm = alg
+		if err = opt.SetPrivateKey(opaqueSigner{key.(crypto.Signer)}); err != nil {
+			t.Fatalf("%s: %v", jwa.GetAlgorithmName(alg), err)
+		}
+
+		token := jwt.NewJWT()
+		token.SetIssuer("fido")
+		if err = Sign(token, opt); err != nil {
+			t.Errorf("%s: %v", jwa.GetAlgorithmName(alg), err)
+		}
+
+		if err = Verify(token, opt); err != nil {
+			t.Errorf("%s: %v", jwa.GetAlgorithmName(alg), err)
+		}
+	}
+
+	//The public key of the signer must still match the algorithm.
+	key, _, _ := jwk.Generate(jwa.ES256)
+	opt := BlankOptions()
+	opt.Algorithm = jwa.RS256
+	if err := opt.SetPrivateKey(opaqueSigner{key.(crypto.Signer)}); !errors.Is(err, jwa.ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidKey, err)
+	}
+}
+
+func Test_Keys_ThumbprintID(t *testing.T) {
+
+	key, k, err := jwk.Generate(jwa.ES384)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opt := BlankOptions()
+	opt.Algorithm = jwa.ES384
+	if err = opt.SetPrivateKey(key); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = opt.SetThumbprintID(); err != nil {
+		t.Fatal(err)
+	}
+
+	thumbprint, _ := k.Thumbprint(crypto.SHA256)
+	if opt.SignID != b64.EncodeURL(thumbprint) {
+		t.Errorf("Unexpected SignID: %s", opt.SignID)
+	}
+
+	secret := BlankOptions()
+	secret.Algorithm = jwa.HS256
+	secret.LoadSecret(testMCKey)
+	if err = secret.SetThumbprintID(); !errors.Is(err, jwa.ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidKey, err)
+	}
+}
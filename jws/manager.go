@@ -0,0 +1,348 @@
+package jws
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+//ErrNoActiveKey means that a KeyManager has no key that can sign at the current time.
+var ErrNoActiveKey = errors.New("no active signing key")
+
+//KeyState is the stage of the life of a managed key at a given time.
+type KeyState int
+
+const (
+	//KeyNext keys are published so verifiers can cache them, but they don't sign yet.
+	KeyNext KeyState = iota
+	//KeyActive keys sign and verify.
+	KeyActive
+	//KeyExpired keys no longer sign, but they still verify the tokens they signed.
+	KeyExpired
+	//KeyRetired keys are neither published nor accepted.
+	KeyRetired
+)
+
+//ManagedKey is a signing key of a KeyManager. It signs from Activation until Expiration,
+//and it's published and accepted from the moment it's added until Overlap after Expiration.
+type ManagedKey struct {
+	KeyID      string
+	Algorithm  jwa.Algorithm
+	Activation time.Time
+	Expiration time.Time
+
+	private crypto.PrivateKey
+	public  jwk.Key
+}
+
+//KeyManager holds the signing keys of an issuer through their rotation.
+//It signs with the active key, verifies with the active and expired keys, and
+//publishes the public keys of the ones that are not retired as a JWK Set.
+//It's safe for concurrent use.
+type KeyManager struct {
+	//Algorithm is the algorithm of the keys created by Rotate.
+	Algorithm jwa.Algorithm
+	//Period is how long each key created by Rotate signs.
+	Period time.Duration
+	//Overlap is how long a key is published before it signs, so the verifiers that
+	//cache the JWK Set know it in advance, and how long it's accepted after it stops.
+	//It should be longer than the lifetime of the tokens and of the caches.
+	Overlap time.Duration
+	//Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+	//OnError is called by Run with the error of every failed Rotate, after which it keeps
+	//rotating. The errors are logged if nil.
+	OnError func(err error)
+
+	mu   sync.RWMutex
+	keys []*ManagedKey
+
+	//rotateMu serializes Rotate, so concurrent calls don't both decide to create a key.
+	rotateMu sync.Mutex
+}
+
+//NewKeyManager returns a KeyManager with no keys. Rotate creates the first ones.
+func NewKeyManager(alg jwa.Algorithm, period, overlap time.Duration) *KeyManager {
+	return &KeyManager{Algorithm: alg, Period: period, Overlap: overlap}
+}
+
+func (m *KeyManager) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
+
+//state returns the stage of k at t, given the overlap window of its KeyManager.
+func (k *ManagedKey) state(t time.Time, overlap time.Duration) KeyState {
+	switch {
+	case t.Before(k.Activation):
+		return KeyNext
+	case t.Before(k.Expiration):
+		return KeyActive
+	case t.Before(k.Expiration.Add(overlap)):
+		return KeyExpired
+	default:
+		return KeyRetired
+	}
+}
+
+//State returns the stage of the key identified by kid at the current time.
+func (m *KeyManager) State(kid string) (KeyState, error) {
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, k := range m.keys {
+		if k.KeyID == kid {
+			return k.state(m.now(), m.Overlap), nil
+		}
+	}
+	return KeyRetired, jwk.ErrKeyNotFound
+}
+
+//AddKey adds an existing key, such as one loaded from a vault, that signs with alg from
+//activation until expiration. kid must be unique among the keys of m.
+func (m *KeyManager) AddKey(alg jwa.Algorithm, key crypto.PrivateKey, kid string, activation, expiration time.Time) error {
+
+	if key == nil || kid == "" || !activation.Before(expiration) {
+		return jwa.ErrInvalidInput
+	}
+
+	if err := checkKeyAlgorithm(key, alg); err != nil {
+		return err
+	}
+
+	public, err := jwk.FromKey(key)
+	if err != nil {
+		return err
+	}
+	public.KeyID = kid
+	public.Algorithm = jwa.GetAlgorithmName(alg)
+	public.Use = jwk.UseSignature
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.keys {
+		if k.KeyID == kid {
+			return jwa.ErrInvalidInput
+		}
+	}
+
+	m.keys = append(m.keys, &ManagedKey{
+		KeyID:      kid,
+		Algorithm:  alg,
+		Activation: activation,
+		Expiration: expiration,
+		private:    key,
+		public:     public.Public(),
+	})
+	sort.SliceStable(m.keys, func(i, j int) bool {
+		return m.keys[i].Activation.Before(m.keys[j].Activation)
+	})
+	return nil
+}
+
+//Rotate follows the schedule of m: it drops the retired keys, creates a key that signs
+//from now if none is active, and creates the next key once the last one is within Overlap
+//of its expiration, so the next key is published before it's needed.
+//It's meant to be called periodically, for example by Run.
+func (m *KeyManager) Rotate() error {
+
+	if m.Period <= 0 || m.Overlap < 0 {
+		return jwa.ErrInvalidInput
+	}
+
+	m.rotateMu.Lock()
+	defer m.rotateMu.Unlock()
+
+	var now = m.now()
+
+	m.mu.Lock()
+	var kept = m.keys[:0]
+	var active = false
+	var start = now
+	for _, k := range m.keys {
+		switch k.state(now, m.Overlap) {
+		case KeyRetired:
+			continue
+		case KeyActive:
+			active = true
+		}
+		if k.Expiration.After(start) {
+			start = k.Expiration
+		}
+		kept = append(kept, k)
+	}
+	m.keys = kept
+	m.mu.Unlock()
+
+	//A next key cannot take the place of the missing active one.
+	if !active {
+		start = now
+	}
+
+	//The keys are created until the last one is still far from its expiration.
+	for !start.After(now.Add(m.Overlap)) {
+		key, k, err := jwk.Generate(m.Algorithm)
+		if err != nil {
+			return err
+		}
+		if err = m.AddKey(m.Algorithm, key, k.KeyID, start, start.Add(m.Period)); err != nil {
+			return err
+		}
+		start = start.Add(m.Period)
+	}
+	return nil
+}
+
+//Run calls Rotate right away and then every interval, until ctx is done, and returns
+//the error of ctx. A failed Rotate is reported to OnError and retried at the next tick,
+//so a transient failure doesn't stop the rotation.
+func (m *KeyManager) Run(ctx context.Context, interval time.Duration) error {
+
+	m.rotate()
+
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.rotate()
+		}
+	}
+}
+
+//rotate calls Rotate and reports its error.
+func (m *KeyManager) rotate() {
+
+	err := m.Rotate()
+	if err == nil {
+		return
+	}
+	if m.OnError != nil {
+		m.OnError(err)
+		return
+	}
+	log.Println("Rotating the signing keys failed:", err)
+}
+
+//Active returns the key that signs at the current time: among the active keys,
+//the one that was activated last.
+func (m *KeyManager) Active() (*ManagedKey, error) {
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var now = m.now()
+	for i := len(m.keys) - 1; i >= 0; i-- {
+		if m.keys[i].state(now, m.Overlap) == KeyActive {
+			return m.keys[i], nil
+		}
+	}
+	return nil, ErrNoActiveKey
+}
+
+//Options returns the Options that sign with the active key, with its kid as SignID.
+func (m *KeyManager) Options() (*Options, error) {
+
+	k, err := m.Active()
+	if err != nil {
+		return nil, err
+	}
+
+	var opt = BlankOptions()
+	opt.Algorithm = k.Algorithm
+	opt.SignID = k.KeyID
+	if err = opt.SetPrivateKey(k.private); err != nil {
+		return nil, err
+	}
+	return opt, nil
+}
+
+//Sign signs j with the active key.
+func (m *KeyManager) Sign(j *jwt.JWT) error {
+
+	opt, err := m.Options()
+	if err != nil {
+		return err
+	}
+	return Sign(j, opt)
+}
+
+//Verify verifies the signature of j whose protected "kid" is an active or expired key of m.
+func (m *KeyManager) Verify(j *jwt.JWT) error {
+
+	if j == nil {
+		return jwa.ErrInvalidInput
+	}
+
+	m.mu.RLock()
+	var now = m.now()
+	var accepted = make(map[string]*ManagedKey, len(m.keys))
+	for _, k := range m.keys {
+		//The next keys are published, but a token they signed means they are misused.
+		if state := k.state(now, m.Overlap); state == KeyActive || state == KeyExpired {
+			accepted[k.KeyID] = k
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, signature := range j.Signatures {
+		header, err := decodeHeader(signature.Protected)
+		if err != nil {
+			continue
+		}
+
+		kid, _ := header["kid"].(string)
+		k, ok := accepted[kid]
+		if !ok {
+			continue
+		}
+
+		var opt = BlankOptions()
+		opt.Algorithm = k.Algorithm
+		opt.SignID = k.KeyID
+		if err = opt.SetPublicKey(publicOf(k.private)); err != nil {
+			return err
+		}
+		return signatureError(opt, verifySignature(j, signature, opt))
+	}
+	return ErrSignatureNotFound
+}
+
+//PublicSet returns the JWK Set of the public keys that are not retired, the next ones included.
+func (m *KeyManager) PublicSet() jwk.Set {
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var now = m.now()
+	var set = jwk.Set{Keys: []jwk.Key{}}
+	for _, k := range m.keys {
+		if k.state(now, m.Overlap) != KeyRetired {
+			set.Keys = append(set.Keys, k.public)
+		}
+	}
+	return set.Public()
+}
+
+//publicOf returns the public key of a private key, or the key itself for the HSXXX secrets.
+func publicOf(key crypto.PrivateKey) crypto.PublicKey {
+	if signer, ok := key.(crypto.Signer); ok {
+		return signer.Public()
+	}
+	return key
+}
@@ -0,0 +1,200 @@
+package jws
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+func signedBy(t *testing.T, m *KeyManager) (*jwt.JWT, string) {
+
+	token := jwt.NewJWT()
+	token.SetIssuer("pepe")
+	if err := m.Sign(token); err != nil {
+		t.Fatal(err)
+	}
+
+	header, _ := decodeHeader(token.Signatures[0].Protected)
+	return token, header["kid"].(string)
+}
+
+func Test_KeyManager_Rotation(t *testing.T) {
+
+	const day = 24 * time.Hour
+	var now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m := NewKeyManager(jwa.ES256, 90*day, 7*day)
+	m.Now = func() time.Time { return now }
+
+	if err := m.Sign(jwt.NewJWT()); !errors.Is(err, ErrNoActiveKey) {
+		t.Errorf("Expected %s, found %v", ErrNoActiveKey, err)
+	}
+
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.PublicSet().Keys); n != 1 {
+		t.Errorf("Expected 1 published key, found %d", n)
+	}
+
+	first, firstID := signedBy(t, m)
+	if err := m.Verify(first); err != nil {
+		t.Error(err)
+	}
+
+	//Within the overlap window the next key is published, but doesn't sign yet.
+	now = now.Add(85 * day)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	set := m.PublicSet()
+	if len(set.Keys) != 2 {
+		t.Fatalf("Expected 2 published keys, found %d", len(set.Keys))
+	}
+	for _, k := range set.Keys {
+		if k.IsPrivate() {
+			t.Errorf("The key %s was published with its private members", k.KeyID)
+		}
+	}
+	nextID := set.Keys[1].KeyID
+	if state, _ := m.State(nextID); state != KeyNext {
+		t.Errorf("Expected the state %d, found %d", KeyNext, state)
+	}
+	if _, kid := signedBy(t, m); kid != firstID {
+		t.Errorf("Expected to sign with %s, found %s", firstID, kid)
+	}
+
+	//A token signed by the next key before its activation is refused.
+	var early = jwt.NewJWT()
+	opt := BlankOptions()
+	opt.Algorithm = jwa.ES256
+	opt.SignID = nextID
+	if err := opt.SetPrivateKey(m.keys[1].private); err != nil {
+		t.Fatal(err)
+	}
+	if err := Sign(early, opt); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Verify(early); !errors.Is(err, ErrSignatureNotFound) {
+		t.Errorf("Expected %s, found %v", ErrSignatureNotFound, err)
+	}
+
+	//The next key signs, and the first one still verifies.
+	now = now.Add(6 * day)
+	second, kid := signedBy(t, m)
+	if kid != nextID {
+		t.Errorf("Expected to sign with %s, found %s", nextID, kid)
+	}
+	if state, _ := m.State(firstID); state != KeyExpired {
+		t.Errorf("Expected the state %d, found %d", KeyExpired, state)
+	}
+	if err := m.Verify(first); err != nil {
+		t.Error(err)
+	}
+	if err := m.Verify(second); err != nil {
+		t.Error(err)
+	}
+
+	//Once the overlap is over, the first key is retired.
+	now = now.Add(7 * day)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Verify(first); !errors.Is(err, ErrSignatureNotFound) {
+		t.Errorf("Expected %s, found %v", ErrSignatureNotFound, err)
+	}
+	if _, err := m.State(firstID); !errors.Is(err, jwk.ErrKeyNotFound) {
+		t.Errorf("Expected %s, found %v", jwk.ErrKeyNotFound, err)
+	}
+	if _, err := m.PublicSet().Lookup(firstID); !errors.Is(err, jwk.ErrKeyNotFound) {
+		t.Errorf("Expected %s, found %v", jwk.ErrKeyNotFound, err)
+	}
+}
+
+func Test_KeyManager_AddKey(t *testing.T) {
+
+	var now = time.Now()
+	m := NewKeyManager(jwa.EdDSA, time.Hour, time.Minute)
+
+	key, k, err := jwk.Generate(jwa.EdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.AddKey(jwa.EdDSA, key, k.KeyID, now.Add(-time.Minute), now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.AddKey(jwa.EdDSA, key, k.KeyID, now, now.Add(time.Hour)); !errors.Is(err, jwa.ErrInvalidInput) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidInput, err)
+	}
+	if err = m.AddKey(jwa.ES256, key, "other", now, now.Add(time.Hour)); !errors.Is(err, jwa.ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidKey, err)
+	}
+
+	token, kid := signedBy(t, m)
+	if kid != k.KeyID {
+		t.Errorf("Expected to sign with %s, found %s", k.KeyID, kid)
+	}
+
+	token.SetIssuer("mallory")
+	if err = m.Verify(token); !errors.Is(err, jwa.ErrAlteredMessage) {
+		t.Errorf("Expected %s, found %v", jwa.ErrAlteredMessage, err)
+	}
+}
+
+func Test_KeyManager_ConcurrentRotate(t *testing.T) {
+
+	m := NewKeyManager(jwa.EdDSA, time.Hour, time.Minute)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.Rotate(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if set := m.PublicSet(); len(set.Keys) != 1 {
+		t.Errorf("Expected a single active key, found %d keys", len(set.Keys))
+	}
+}
+
+func Test_KeyManager_Run(t *testing.T) {
+
+	//A manager that cannot rotate keeps trying.
+	var failures int32
+	broken := NewKeyManager(jwa.EdDSA, 0, time.Minute)
+	broken.OnError = func(err error) {
+		if errors.Is(err, jwa.ErrInvalidInput) {
+			atomic.AddInt32(&failures, 1)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	if err := broken.Run(ctx, 10*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %s, found %v", context.DeadlineExceeded, err)
+	}
+	if n := atomic.LoadInt32(&failures); n < 2 {
+		t.Errorf("Expected the failures to be retried, found %d", n)
+	}
+
+	m := NewKeyManager(jwa.EdDSA, time.Hour, time.Minute)
+	ctx, cancel = context.WithCancel(t.Context())
+	cancel()
+	m.Run(ctx, time.Hour)
+	if _, err := m.Active(); err != nil {
+		t.Error(err)
+	}
+}
@@ -2,10 +2,12 @@ package jws
 
 import (
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/x509"
-	"errors"
 
+	"github.com/vegaj/JOSE/b64"
 	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
 )
 
 //Options to perform a signature.
@@ -14,6 +16,31 @@ type Options struct {
 	Algorithm jwa.Algorithm
 	//Identifier for this signature.
 	SignID string
+	//Deterministic makes the ECDSA algorithms use the nonces of RFC 6979, so the same
+	//message always has the same signature. The key must be an *ecdsa.PrivateKey.
+	//It doesn't change the other algorithms.
+	Deterministic bool
+	//LowS makes the ECDSA signatures have a S in the lower half of the curve order, and
+	//rejects the ones that don't on verification, so the signatures cannot be malleated.
+	LowS bool
+
+	//Type is the "typ" header parameter of the signatures, such as "JWT" or "at+jwt".
+	//It replaces the one of the JWT header, if any.
+	Type string
+	//ExpectedType, when not empty, is the "typ" that the protected header must have to verify.
+	//The comparison ignores case and the "application/" prefix.
+	ExpectedType string
+
+	//EmbedKey writes the public key into the "jwk" header parameter of the signatures.
+	EmbedKey bool
+	//Certificates is the X.509 chain of the key, leaf first. The leaf must hold the public key.
+	Certificates []*x509.Certificate
+	//EmbedCertificates writes Certificates into the "x5c" header parameter.
+	EmbedCertificates bool
+	//EmbedThumbprints writes the SHA-1 and SHA-256 thumbprints of the leaf certificate
+	//into the "x5t" and "x5t#S256" header parameters.
+	EmbedThumbprints bool
+
 	keySet DigitalSignatureKeySet
 }
 
@@ -44,59 +71,71 @@ func (opt *Options) Private() crypto.PrivateKey {
 	return opt.keySet.Private()
 }
 
-//LoadPrivateKey takes a DER encoded PrivateKey and an algorithm to be used to sign.
-//The keys for EllipticCurve must be in the a ASN.1 DER form.
-//In the case of RSA only the ASN.1 PKCS#1 DER format is allowed.
+//LoadPrivateKey takes a PEM or DER encoded PrivateKey to be used to sign with opt.Algorithm.
+//The PKCS#8, PKCS#1 (RSA) and SEC 1 (EC) forms are detected automatically, and the
+//key type must match opt.Algorithm. If no public key was loaded, the one of the
+//private key is used to verify.
 func (opt *Options) LoadPrivateKey(privateKey []byte) error {
-	var k crypto.PrivateKey
-	var err error
-	switch opt.Algorithm {
-	case jwa.ES256, jwa.ES384, jwa.ES512:
-		k, err = x509.ParseECPrivateKey(privateKey)
-		if err != nil {
-			return err
-		}
-	case jwa.RS256, jwa.RS384, jwa.RS512:
-		k, err = x509.ParsePKCS1PrivateKey(privateKey)
-		if err != nil {
-			return err
-		}
-	default:
-		return errors.New(jwa.ErrInvalidAlgorithm)
+
+	k, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	return opt.SetPrivateKey(k)
+}
+
+//LoadPublicKey takes a PEM or DER encoded PublicKey to be used to verify with opt.Algorithm.
+//The PKIX (SubjectPublicKeyInfo) and PKCS#1 (RSA) forms are detected automatically,
+//and X.509 certificates are accepted too. The key type must match opt.Algorithm.
+func (opt *Options) LoadPublicKey(publicKey []byte) error {
+
+	k, err := parsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	return opt.SetPublicKey(k)
+}
+
+//SetPrivateKey takes a key that is already parsed, such as the ones created by jwk.Generate.
+//It behaves like LoadPrivateKey, and the secrets of the HSXXX algorithms are given to LoadSecret.
+//Any crypto.Signer can be used to sign, so keys that never leave a HSM, a PKCS#11 token
+//or a cloud KMS can be plugged through an adapter that implements it.
+func (opt *Options) SetPrivateKey(k crypto.PrivateKey) error {
+
+	if secret, ok := k.([]byte); ok {
+		return opt.LoadSecret(secret)
+	}
+
+	if err := checkKeyAlgorithm(k, opt.Algorithm); err != nil {
+		return err
 	}
 
 	var digs = digSign{
 		pk:  k,
-		pub: opt.keySet.Public(),
+		pub: opt.Public(),
+	}
+
+	if digs.pub == nil {
+		if signer, ok := k.(crypto.Signer); ok {
+			digs.pub = signer.Public()
+		}
 	}
 
 	opt.keySet = digs
 	return nil
 }
 
-//LoadPublicKey takes a DER encoded PublicKey and an algorithm to be used to verify.
-//The keys for EC must be an PKIX form.
-//In the case of RSA only a ASN.1 PKCS#1 DER public key is allowed.
-func (opt *Options) LoadPublicKey(publicKey []byte) error {
-	var k crypto.PublicKey
-	var err error
-	switch opt.Algorithm {
-	case jwa.ES256, jwa.ES384, jwa.ES512:
-		k, err = x509.ParsePKIXPublicKey(publicKey)
-		if err != nil {
-			return err
-		}
-	case jwa.RS256, jwa.RS384, jwa.RS512:
-		k, err = x509.ParsePKCS1PublicKey(publicKey)
-		if err != nil {
-			return err
-		}
-	default:
-		return errors.New(jwa.ErrInvalidAlgorithm)
+//SetPublicKey takes a key that is already parsed. It behaves like LoadPublicKey.
+func (opt *Options) SetPublicKey(k crypto.PublicKey) error {
+
+	if err := checkKeyAlgorithm(k, opt.Algorithm); err != nil {
+		return err
 	}
 
 	var digs = digSign{
-		pk:  opt.keySet.Private(),
+		pk:  opt.Private(),
 		pub: k,
 	}
 
@@ -104,6 +143,62 @@ func (opt *Options) LoadPublicKey(publicKey []byte) error {
 	return nil
 }
 
+//LoadSecret takes the shared key used by the HSXXX algorithms to both sign and verify.
+//The key must be at least as long as the hash output: https://tools.ietf.org/html/rfc7518#section-3.2
+func (opt *Options) LoadSecret(secret []byte) error {
+	impl, err := jwa.GetImplementation(opt.Algorithm)
+	if err != nil || impl.KeyType != `oct` {
+		return jwa.ErrInvalidAlgorithm
+	}
+
+	if impl.Hash != 0 && len(secret) < impl.Hash.Size() {
+		return jwa.ErrInvalidKeyLength
+	}
+
+	var key = make([]byte, len(secret))
+	copy(key, secret)
+	opt.keySet = digSign{pk: key, pub: key}
+	return nil
+}
+
+//SetThumbprintID sets SignID to the RFC 7638 SHA-256 thumbprint of the public key,
+//base64url encoded, so it can be derived from the key on both sides: https://tools.ietf.org/html/rfc7638#section-1
+//The HSXXX secrets are refused, as their thumbprint would be sent in every signature.
+func (opt *Options) SetThumbprintID() error {
+
+	if _, ok := opt.Public().([]byte); ok {
+		return jwa.ErrInvalidKey
+	}
+
+	key, err := jwk.FromKey(opt.Public())
+	if err != nil {
+		return err
+	}
+
+	thumbprint, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return err
+	}
+
+	opt.SignID = b64.EncodeURL(thumbprint)
+	return nil
+}
+
+//signingKey returns the key that signs with impl, honoring opt.Deterministic.
+func (opt *Options) signingKey(impl jwa.Implementation) (crypto.PrivateKey, error) {
+
+	var key = opt.Private()
+	if !opt.Deterministic || impl.KeyType != `EC` {
+		return key, nil
+	}
+
+	priv, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, jwa.ErrInvalidKey
+	}
+	return jwa.DeterministicSigner(priv), nil
+}
+
 func (d digSign) Public() crypto.PublicKey {
 	return d.pub
 }
@@ -1,6 +1,7 @@
 package jws
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/vegaj/JOSE/jwa"
@@ -82,7 +83,7 @@ func Test_RSADifferentKeys(t *testing.T) {
 
 	if err = rsaVerify(message, signature, opt); err == nil {
 		t.Errorf("Error missed")
-	} else if err.Error() != jwa.ErrAlteredMessage {
+	} else if !errors.Is(err, jwa.ErrAlteredMessage) {
 		t.Errorf("Expected <%s>. Found <%v>", jwa.ErrAlteredMessage, err)
 	}
 }
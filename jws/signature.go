@@ -2,7 +2,6 @@ package jws
 
 import (
 	"encoding/json"
-	"errors"
 
 	"github.com/vegaj/JOSE/b64"
 
@@ -10,13 +9,6 @@ import (
 	"github.com/vegaj/JOSE/jwt"
 )
 
-const (
-	//ErrSignatureNotFound means that the signature with a certain kid is missing.
-	ErrSignatureNotFound = `signature not found`
-	//ErrHeaderNotFound means that the signature was expected to have a header, which was found to be malformed or not present.
-	ErrHeaderNotFound = `header not found`
-)
-
 /*
 func unmarshalPrivate(alg jwa.Algorithm, key []byte) (crypto.PrivateKey, error) {
 	switch alg {
@@ -25,9 +17,9 @@ func unmarshalPrivate(alg jwa.Algorithm, key []byte) (crypto.PrivateKey, error)
 	case jwa.ES256, jwa.ES384, jwa.ES512:
 		return x509.ParseECPrivateKey(key)
 	case jwa.HS256, jwa.HS384, jwa.HS512:
-		return nil, errors.New(jwa.ErrInvalidKey)
+		return nil, jwa.ErrInvalidKey
 	default:
-		return nil, errors.New(jwa.ErrInvalidAlgorithm)
+		return nil, jwa.ErrInvalidAlgorithm
 	}
 }
 
@@ -38,165 +30,170 @@ func unmarshalPublic(alg jwa.Algorithm, key []byte) (crypto.PublicKey, error) {
 	case jwa.ES256, jwa.ES384, jwa.ES512:
 		return x509.ParsePKIXPublicKey(key)
 	case jwa.HS256, jwa.HS384, jwa.HS512:
-		return nil, errors.New(jwa.ErrInvalidKey)
+		return nil, jwa.ErrInvalidKey
 	default:
-		return nil, errors.New(jwa.ErrInvalidAlgorithm)
+		return nil, jwa.ErrInvalidAlgorithm
 	}
 }
 */
 
 //Sign will sign the input JWT according to opt.
+//The JWS Protected Header of the new signature is made out of j.Header plus
+//the "alg" and "kid" parameters, and the JWS Signing Input is computed as
+//described here: https://tools.ietf.org/html/rfc7515#section-5.1
+//No "typ" is added unless j.Header or opt.Type set one, such as "JWT" or "at+jwt":
+//https://tools.ietf.org/html/rfc8725#section-3.11
 func Sign(j *jwt.JWT, opt *Options) error {
 
 	if j == nil || opt == nil {
-		return errors.New(jwa.ErrInvalidInput)
+		return jwa.ErrInvalidInput
 	}
 
 	var err error
-	var message []byte
 	var signature jwt.Signature
 
-	j.Header["typ"] = "JWS"
-
-	if message, err = createMessage(j); err != nil {
+	if signature.Header, err = protectedHeader(j.Header, opt); err != nil {
 		return err
 	}
 
-	signature.Header = make(map[string]interface{})
-	signature.Header["alg"] = jwa.GetAlgorithmName(opt.Algorithm)
-	signature.Header["kid"] = opt.SignID
-
-	var protected, encodedSignature string
+	if err = checkCritical(signature.Header); err != nil {
+		return err
+	}
 
 	protectedHeaderJSON, err := json.Marshal(signature.Header)
 	if err != nil {
 		return err
 	}
 
-	switch opt.Algorithm {
-	case jwa.HS256, jwa.HS384, jwa.HS512:
-		return errors.New("unimplemented")
-	case jwa.ES256, jwa.ES384, jwa.ES512:
-		//Perform Elliptic Signature
-		sig, err := EllipticSign(message, opt)
-		if err != nil {
-			return err
-		}
-
-		prot, err := EllipticSign(protectedHeaderJSON, opt)
-		if err != nil {
-			return err
-		}
-
-		protected = b64.EncodeURL(prot)
-		encodedSignature = b64.EncodeURL(sig)
-
-	case jwa.RS256, jwa.RS384, jwa.RS512:
-		//Perform RSA signature.
-		sig, err := rsaSignature(message, opt)
-		if err != nil {
-			return err
-		}
-
-		prot, err := rsaSignature(protectedHeaderJSON, opt)
-		if err != nil {
-			return err
-		}
+	var protected = b64.EncodeURL(protectedHeaderJSON)
+	message, err := createMessage(protected, signature.Header, j)
+	if err != nil {
+		return err
+	}
 
-		protected = b64.EncodeURL(prot)
-		encodedSignature = b64.EncodeURL(sig)
-	default:
-		return errors.New(jwa.ErrInvalidAlgorithm)
+	sig, err := signMessage(message, opt)
+	if err != nil {
+		return err
 	}
 
 	signature.Protected = protected
-	signature.Signature = encodedSignature
+	signature.Signature = b64.EncodeURL(sig)
 	j.Signatures = append(j.Signatures, signature)
 
 	return nil
 }
 
 //Verify will ensure that the signature with the same SignID as in opt.
+//Only the parameters found in the JWS Protected Header are taken into account
+//and every parameter listed in its "crit" header must be understood by
+//a registered CriticalHandler.
 func Verify(j *jwt.JWT, opt *Options) error {
 
 	if j == nil || opt == nil {
-		return errors.New(jwa.ErrInvalidInput)
+		return jwa.ErrInvalidInput
 	}
 
-	var err error
-	var signature jwt.Signature
-	var signatureHeaderJSON []byte
+	signature, err := findTargetSignature(j.Signatures, opt)
+	if err != nil {
+		return err
+	}
+
+	return signatureError(opt, verifySignature(j, signature, opt))
+}
 
-	signature, err = findTargetSignature(j.Signatures, opt)
+func verifySignature(j *jwt.JWT, signature jwt.Signature, opt *Options) error {
 
+	header, err := decodeHeader(signature.Protected)
 	if err != nil {
 		return err
 	}
 
-	message, err := createMessage(j)
-	if err != nil {
+	if err = checkHeader(header, opt); err != nil {
 		return err
 	}
 
-	var protected = b64.DecodeURL(signature.Protected)
-	var sign = b64.DecodeURL(signature.Signature)
+	if err = checkCritical(header); err != nil {
+		return err
+	}
 
-	if err = checkHeader(signature.Header, opt); err != nil {
+	message, err := createMessage(signature.Protected, header, j)
+	if err != nil {
 		return err
 	}
 
-	if signatureHeaderJSON, err = json.Marshal(signature.Header); err != nil {
+	sign, err := b64.DecodeURLStrict(signature.Signature)
+	if err != nil {
 		return err
 	}
 
-	switch opt.Algorithm {
-	case jwa.HS256, jwa.HS384, jwa.HS512:
-		return errors.New("unimplemented")
-	case jwa.ES256, jwa.ES384, jwa.ES512:
+	return verifyMessage(message, sign, opt)
+}
 
-		if err = EllipticVerify(message, sign, opt); err != nil {
-			return err
-		}
+//protectedHeader returns a copy of header with the parameters that identify the signature of opt,
+//and the key or certificates that opt embeds.
+func protectedHeader(header map[string]interface{}, opt *Options) (map[string]interface{}, error) {
+	var protected = make(map[string]interface{}, len(header)+2)
+	for k, v := range header {
+		protected[k] = v
+	}
+	protected["alg"] = jwa.GetAlgorithmName(opt.Algorithm)
+	protected["kid"] = opt.SignID
+	if opt.Type != "" {
+		protected["typ"] = opt.Type
+	}
 
-		if err = EllipticVerify(signatureHeaderJSON, protected, opt); err != nil {
-			return err
-		}
+	if err := embedSigner(protected, opt); err != nil {
+		return nil, err
+	}
+	return protected, nil
+}
 
-	case jwa.RS256, jwa.RS384, jwa.RS512:
+//createMessage builds the JWS Signing Input: the encoded protected header and
+//the payload joined by a dot. The payload is base64url encoded unless the header
+//carries "b64": false, listed in "crit" (https://tools.ietf.org/html/rfc7797#section-3).
+func createMessage(protected string, header map[string]interface{}, j *jwt.JWT) ([]byte, error) {
+	var err error
+	var payloadJSON []byte
 
-		if err = rsaVerify(message, sign, opt); err != nil {
-			return err
-		}
+	if payloadJSON, err = j.PayloadJSON(); err != nil {
+		return nil, err
+	}
 
-		if err = rsaVerify(signatureHeaderJSON, protected, opt); err != nil {
-			return err
-		}
+	unencoded, err := jwt.UnencodedPayload(header)
+	if err != nil {
+		return nil, err
+	}
 
-	default:
-		return errors.New(jwa.ErrInvalidAlgorithm)
+	if unencoded {
+		return append([]byte(protected+"."), payloadJSON...), nil
 	}
 
-	return nil
+	return []byte(protected + "." + b64.EncodeURL(payloadJSON)), nil
 }
 
-func createMessage(j *jwt.JWT) ([]byte, error) {
-	var err error
-	var headerJSON, payloadJSON []byte
-	if headerJSON, err = json.Marshal(j.Header); err != nil {
-		return nil, err
+//decodeHeader returns the JSON object contained in a base64url encoded header.
+func decodeHeader(protected string) (map[string]interface{}, error) {
+
+	if protected == "" {
+		return nil, ErrHeaderNotFound
 	}
 
-	if payloadJSON, err = json.Marshal(j.Payload); err != nil {
+	raw, err := b64.DecodeURLStrict(protected)
+	if err != nil {
 		return nil, err
 	}
 
-	return []byte(b64.EncodeURL(headerJSON) + "." + b64.EncodeURL(payloadJSON)), nil
+	var header map[string]interface{}
+	if err = json.Unmarshal(raw, &header); err != nil || header == nil {
+		return nil, ErrHeaderNotFound
+	}
+	return header, nil
 }
 
 func findTargetSignature(sigs []jwt.Signature, opt *Options) (jwt.Signature, error) {
 
 	if len(sigs) == 0 {
-		return jwt.Signature{}, errors.New(ErrSignatureNotFound)
+		return jwt.Signature{}, ErrSignatureNotFound
 	}
 
 	if len(sigs) == 1 {
@@ -209,22 +206,37 @@ func findTargetSignature(sigs []jwt.Signature, opt *Options) (jwt.Signature, err
 		}
 	}
 
-	return jwt.Signature{}, errors.New(ErrSignatureNotFound)
+	return jwt.Signature{}, ErrSignatureNotFound
 }
 
 func checkHeader(header map[string]interface{}, opt *Options) error {
 
 	if header == nil {
-		return errors.New(ErrHeaderNotFound)
+		return ErrHeaderNotFound
 	}
 
-	if algName, ok := header["alg"]; ok {
-		if alg, ok := algName.(string); ok {
-			if jwa.AlgorithmFromName(alg) == opt.Algorithm {
-				return nil
-			}
-			return errors.New(jwa.ErrInvalidAlgorithm)
-		}
+	alg, ok := header["alg"].(string)
+	if !ok {
+		return ErrHeaderNotFound
+	}
+	if jwa.AlgorithmFromName(alg) != opt.Algorithm {
+		return jwa.ErrInvalidAlgorithm
 	}
-	return errors.New(ErrHeaderNotFound)
+
+	return checkType(header, opt.ExpectedType)
+}
+
+//checkType returns jwt.ErrInvalidType if expected is not empty and the "typ" of header doesn't match it.
+//Requiring the type of each kind of JWT keeps one from being accepted as another:
+//https://tools.ietf.org/html/rfc8725#section-3.11
+func checkType(header map[string]interface{}, expected string) error {
+
+	if expected == "" {
+		return nil
+	}
+
+	if typ, _ := header["typ"].(string); !jwt.TypeMatches(typ, expected) {
+		return jwt.ErrInvalidType
+	}
+	return nil
 }
@@ -1,10 +1,15 @@
 package jws
 
 import (
+	"crypto"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
 	"github.com/vegaj/JOSE/jwt"
 )
 
@@ -152,7 +157,7 @@ func Test_JWS_InvalidES256(t *testing.T) {
 
 	if err := Sign(token, opt); err == nil {
 		t.Error("error missed")
-	} else if err.Error() != jwa.ErrInvalidCurve {
+	} else if !errors.Is(err, jwa.ErrInvalidCurve) {
 		t.Errorf("Expected %s, found %v", jwa.ErrInvalidCurve, err)
 	}
 }
@@ -179,7 +184,7 @@ func Test_JWS_SameSignID(t *testing.T) {
 
 	if err := Verify(token, opt2); err == nil {
 		t.Error("missed error")
-	} else if err.Error() != jwa.ErrAlteredMessage {
+	} else if !errors.Is(err, jwa.ErrAlteredMessage) {
 		t.Errorf("Expected %s, found %v", jwa.ErrAlteredMessage, err)
 	}
 
@@ -194,7 +199,7 @@ func Test_JWS_SameSignID(t *testing.T) {
 
 	if err := Verify(token, opt2); err == nil {
 		t.Error("missed error")
-	} else if err.Error() != jwa.ErrAlteredMessage {
+	} else if !errors.Is(err, jwa.ErrAlteredMessage) {
 		t.Errorf("Expected %s, found %v", jwa.ErrAlteredMessage, err)
 	}
 }
@@ -206,7 +211,7 @@ func Test_JWS_NilCals(t *testing.T) {
 
 	if err := Sign(token, opt); err == nil {
 		t.Error("missed err")
-	} else if err.Error() != jwa.ErrInvalidInput {
+	} else if !errors.Is(err, jwa.ErrInvalidInput) {
 		t.Errorf("Expected %s, found %v", jwa.ErrInvalidInput, err)
 	}
 }
@@ -248,3 +253,311 @@ func Test_JWS_Integrity(t *testing.T) {
 	}
 
 }
+
+func Test_JWS_PS256(t *testing.T) {
+
+	var opt = NewOptions(jwa.PS256, testRSAKey, testRSAPubKey, "pepe-pss")
+
+	var token = jwt.NewJWT()
+	token.SetIssuer("pepe")
+
+	if err := Sign(token, opt); err != nil {
+		t.Error(err)
+	}
+
+	if err := Verify(token, opt); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_JWS_HS512(t *testing.T) {
+
+	var opt = BlankOptions()
+	opt.Algorithm = jwa.HS512
+	opt.SignID = "pepe-mac"
+	if err := opt.LoadSecret(testMCKey); err != nil {
+		t.Fatal(err)
+	}
+
+	var other = BlankOptions()
+	other.Algorithm = jwa.HS512
+	other.SignID = "pepe-mac"
+	other.LoadSecret(testMCKey2)
+
+	var token = jwt.NewJWT()
+	token.SetIssuer("pepe")
+
+	if err := Sign(token, opt); err != nil {
+		t.Error(err)
+	}
+
+	if err := Verify(token, opt); err != nil {
+		t.Error(err)
+	}
+
+	if err := Verify(token, other); err == nil {
+		t.Error("missed error")
+	} else if !errors.Is(err, jwa.ErrAlteredMessage) {
+		t.Errorf("Expected %s, found %v", jwa.ErrAlteredMessage, err)
+	}
+
+	if err := opt.LoadSecret(testMCKey[:32]); err == nil {
+		t.Error("short secrets must be rejected")
+	} else if !errors.Is(err, jwa.ErrInvalidKeyLength) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidKeyLength, err)
+	}
+}
+
+func Test_JWS_SignatureError(t *testing.T) {
+
+	var opt = NewOptions(jwa.ES256, testP256Key, testP256PubKey, "pepe")
+	var token = jwt.NewJWT()
+	token.SetIssuer("pepe")
+
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	token.SetIssuer("fido")
+	err := Verify(token, opt)
+
+	var serr *SignatureError
+	if !errors.As(err, &serr) {
+		t.Fatalf("Expected a SignatureError, found %v", err)
+	}
+
+	if serr.KeyID != "pepe" || serr.Algorithm != jwa.ES256Name {
+		t.Errorf("Unexpected signature identification: %+v", serr)
+	}
+
+	if !errors.Is(err, jwa.ErrAlteredMessage) {
+		t.Errorf("Expected %s, found %v", jwa.ErrAlteredMessage, err)
+	}
+}
+
+func Test_JWS_GeneratedKeys(t *testing.T) {
+
+	for _, alg := range []jwa.Algorithm{jwa.HS384, jwa.PS256, jwa.ES384, jwa.ES256K, jwa.EdDSA, jwa.MLDSA44} {
+		key, k, err := jwk.Generate(alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var opt = BlankOptions()
+		opt.Algorithm = alg
+		opt.SignID = k.KeyID
+		if err = opt.SetPrivateKey(key); err != nil {
+			t.Fatalf("%s: %v", k.Algorithm, err)
+		}
+
+		var token = jwt.NewJWT()
+		token.SetIssuer("pepe")
+
+		if err = Sign(token, opt); err != nil {
+			t.Errorf("%s: %v", k.Algorithm, err)
+		}
+
+		if err = Verify(token, opt); err != nil {
+			t.Errorf("%s: %v", k.Algorithm, err)
+		}
+	}
+}
+
+//A token signed both with a classic and a post-quantum algorithm stays valid
+//while verifiers migrate from one to the other.
+func Test_JWS_CompositeMLDSA(t *testing.T) {
+
+	var opts []*Options
+	for _, alg := range []jwa.Algorithm{jwa.ES256, jwa.MLDSA65} {
+		key, k, err := jwk.Generate(alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var opt = BlankOptions()
+		opt.Algorithm = alg
+		opt.SignID = k.KeyID
+		if err = opt.SetPrivateKey(key); err != nil {
+			t.Fatal(err)
+		}
+		opts = append(opts, opt)
+	}
+
+	var token = jwt.NewJWT()
+	token.SetIssuer("pepe")
+
+	for _, opt := range opts {
+		if err := Sign(token, opt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(token.Signatures) != 2 {
+		t.Fatalf("Expected 2 signatures, found %d", len(token.Signatures))
+	}
+
+	for _, opt := range opts {
+		if err := Verify(token, opt); err != nil {
+			t.Errorf("%s: %v", jwa.GetAlgorithmName(opt.Algorithm), err)
+		}
+	}
+
+	//ML-DSA signs the whole message, so it cannot be streamed.
+	if err := SignStream(io.Discard, nil, strings.NewReader("{}"), opts[1]); !errors.Is(err, jwa.ErrInvalidAlgorithm) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidAlgorithm, err)
+	}
+}
+
+func Test_JWS_Deterministic(t *testing.T) {
+
+	for _, alg := range []jwa.Algorithm{jwa.ES256, jwa.ES512, jwa.ES256K, jwa.RS256} {
+		key, _, err := jwk.Generate(alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var opt = BlankOptions()
+		opt.Algorithm = alg
+		opt.Deterministic = true
+		if err = opt.SetPrivateKey(key); err != nil {
+			t.Fatal(err)
+		}
+
+		var signatures []string
+		for i := 0; i < 2; i++ {
+			var token = jwt.NewJWT()
+			token.SetIssuer("pepe")
+			if err = Sign(token, opt); err != nil {
+				t.Fatalf("%s: %v", jwa.GetAlgorithmName(alg), err)
+			}
+
+			if err = Verify(token, opt); err != nil {
+				t.Errorf("%s: %v", jwa.GetAlgorithmName(alg), err)
+			}
+			signatures = append(signatures, token.Signatures[0].Signature)
+		}
+
+		if signatures[0] != signatures[1] {
+			t.Errorf("%s: the signatures must be equal", jwa.GetAlgorithmName(alg))
+		}
+	}
+
+	//Only the private key itself can compute the nonces.
+	key, _, _ := jwk.Generate(jwa.ES256)
+	var opt = BlankOptions()
+	opt.Algorithm = jwa.ES256
+	opt.Deterministic = true
+	if err := opt.SetPrivateKey(opaqueSigner{key.(crypto.Signer)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Sign(jwt.NewJWT(), opt); !errors.Is(err, jwa.ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidKey, err)
+	}
+}
+
+func Test_JWS_AccessTokenType(t *testing.T) {
+
+	m := NewKeyManager(jwa.ES256, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	profile := jwt.AccessToken{
+		Issuer:   "https://as.example.com",
+		Subject:  "5ba552d67",
+		ClientID: "s6BhdRkqt3",
+		Audience: []string{"https://rs.example.com"},
+		Lifetime: time.Minute,
+	}
+	token, err := profile.NewJWT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Sign(token); err != nil {
+		t.Fatal(err)
+	}
+
+	compact, _ := token.CompactSerialization()
+	received, err := jwt.Deserialize(compact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Verify(&received); err != nil {
+		t.Fatal(err)
+	}
+
+	v := jwt.Validator{Issuer: "https://as.example.com", Audience: "https://rs.example.com"}
+	if err = jwt.ValidateAccessToken(received, v); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_JWS_Type(t *testing.T) {
+
+	opt := BlankOptions()
+	opt.Algorithm = jwa.HS256
+	opt.SignID = "pepe"
+	opt.LoadSecret(testMCKey)
+
+	typeOf := func(token *jwt.JWT) interface{} {
+		header, _ := decodeHeader(token.Signatures[len(token.Signatures)-1].Protected)
+		return header["typ"]
+	}
+
+	//No type is made up.
+	token := jwt.NewJWT()
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+	if typ, ok := token.Header["typ"]; ok || typeOf(token) != nil {
+		t.Errorf("Unexpected typ: %v", typ)
+	}
+
+	//The one of the caller is kept, unless opt sets another.
+	token = jwt.NewJWT()
+	token.Header["typ"] = "secevent+jwt"
+	Sign(token, opt)
+	if typ := typeOf(token); typ != "secevent+jwt" {
+		t.Errorf("Unexpected typ: %v", typ)
+	}
+
+	opt.Type = jwt.TypeJWT
+	Sign(token, opt)
+	if typ := typeOf(token); typ != jwt.TypeJWT {
+		t.Errorf("Unexpected typ: %v", typ)
+	}
+
+	var tests = []struct {
+		typ      string
+		expected string
+		err      error
+	}{
+		{"JWT", "JWT", nil},
+		{"jwt", "application/JWT", nil},
+		{"application/dpop+jwt", "dpop+jwt", nil},
+		{"JWT", "at+jwt", jwt.ErrInvalidType},
+		{"", "at+jwt", jwt.ErrInvalidType},
+		{"", "", nil},
+	}
+
+	for _, tt := range tests {
+		signer := *opt
+		signer.Type = tt.typ
+		token := jwt.NewJWT()
+		if err := Sign(token, &signer); err != nil {
+			t.Fatal(err)
+		}
+
+		verifier := *opt
+		verifier.ExpectedType = tt.expected
+		if err := Verify(token, &verifier); !errors.Is(err, tt.err) {
+			t.Errorf("%q, %q: Expected %v, found %v", tt.typ, tt.expected, tt.err, err)
+		}
+
+		err := RequireType(tt.expected, VerifierFunc(func(j *jwt.JWT) error { return Verify(j, opt) })).Verify(token)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%q, %q: Expected %v, found %v", tt.typ, tt.expected, tt.err, err)
+		}
+	}
+}
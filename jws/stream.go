@@ -0,0 +1,217 @@
+package jws
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+const (
+	//Upper bounds for the segments that are kept in memory while streaming.
+	maxStreamHeader    = 64 << 10
+	maxStreamSignature = 16 << 10
+)
+
+//SignStream writes to w the compact serialization of a JWS whose payload is read from payload.
+//The payload is encoded and hashed incrementally, so it's never held in memory.
+//The JWS Protected Header is made out of header plus the "alg" and "kid" parameters of opt.
+//Algorithms without digest functions, such as EdDSA, need the whole message, so they cannot be streamed.
+func SignStream(w io.Writer, header map[string]interface{}, payload io.Reader, opt *Options) error {
+
+	if w == nil || payload == nil || opt == nil {
+		return jwa.ErrInvalidInput
+	}
+
+	protected, err := protectedHeader(header, opt)
+	if err != nil {
+		return err
+	}
+
+	if err = checkCritical(protected); err != nil {
+		return err
+	}
+
+	protectedJSON, err := json.Marshal(protected)
+	if err != nil {
+		return err
+	}
+
+	h, err := newHash(opt, opt.Private())
+	if err != nil {
+		return err
+	}
+
+	var out = io.MultiWriter(w, h)
+	if _, err = io.WriteString(out, b64.EncodeURL(protectedJSON)+"."); err != nil {
+		return err
+	}
+
+	unencoded, err := jwt.UnencodedPayload(protected)
+	if err != nil {
+		return err
+	}
+
+	if unencoded {
+		//The unencoded payload cannot contain the separator: https://tools.ietf.org/html/rfc7797#section-5.2
+		if _, err = io.Copy(periodGuard{out}, payload); err != nil {
+			return err
+		}
+	} else {
+		var enc = b64.NewEncoder(out)
+		if _, err = io.Copy(enc, payload); err != nil {
+			return err
+		}
+		if err = enc.Close(); err != nil {
+			return err
+		}
+	}
+
+	signature, err := signDigest(h.Sum(nil), opt)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "."+b64.EncodeURL(signature))
+	return err
+}
+
+//VerifyStream reads the compact serialization of a JWS from r, writes its decoded payload to dst
+//and verifies its signature with opt. The JWS Protected Header is returned on success.
+//The payload is written to dst as it's read, so its content must not be trusted
+//until VerifyStream returns a nil error.
+func VerifyStream(r io.Reader, dst io.Writer, opt *Options) (map[string]interface{}, error) {
+
+	if r == nil || dst == nil || opt == nil {
+		return nil, jwa.ErrInvalidInput
+	}
+
+	var rd = bufio.NewReader(r)
+
+	protected, err := io.ReadAll(&segmentReader{r: rd, limit: maxStreamHeader})
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := decodeHeader(string(protected))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkHeader(header, opt); err != nil {
+		return nil, signatureError(opt, err)
+	}
+
+	if err = checkCritical(header); err != nil {
+		return nil, err
+	}
+
+	h, err := newHash(opt, opt.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	h.Write(protected)
+	h.Write([]byte{'.'})
+
+	unencoded, err := jwt.UnencodedPayload(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload io.Reader = io.TeeReader(&segmentReader{r: rd, limit: -1}, h)
+	if !unencoded {
+		payload = b64.NewDecoder(payload)
+	}
+
+	if _, err = io.Copy(dst, payload); err != nil {
+		return nil, err
+	}
+
+	encoded, err := io.ReadAll(io.LimitReader(rd, maxStreamSignature+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encoded) == 0 || len(encoded) > maxStreamSignature {
+		return nil, ErrMalformedStream
+	}
+
+	signature, err := b64.DecodeURLStrict(string(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = verifyDigest(h.Sum(nil), signature, opt); err != nil {
+		return nil, signatureError(opt, err)
+	}
+	return header, nil
+}
+
+//segmentReader reads from r up to the next '.' separator, which is consumed but not returned.
+//Reading more than limit bytes is an error, unless limit is negative.
+type segmentReader struct {
+	r     *bufio.Reader
+	limit int
+	read  int
+	done  bool
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	if s.done {
+		return 0, io.EOF
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	//Make sure there is something buffered, then work on what's already there.
+	if _, err := s.r.Peek(1); err != nil {
+		if err == io.EOF {
+			err = ErrMalformedStream
+		}
+		return 0, err
+	}
+
+	buf, _ := s.r.Peek(s.r.Buffered())
+	if len(buf) > len(p) {
+		buf = buf[:len(p)]
+	}
+
+	if i := bytes.IndexByte(buf, '.'); i >= 0 {
+		buf = buf[:i]
+		s.done = true
+	}
+
+	var n = copy(p, buf)
+	s.read += n
+	if s.limit >= 0 && s.read > s.limit {
+		return 0, ErrMalformedStream
+	}
+
+	s.r.Discard(n)
+	if s.done {
+		s.r.Discard(1)
+		if n == 0 {
+			return 0, io.EOF
+		}
+	}
+	return n, nil
+}
+
+//periodGuard refuses to write data that contains a '.'
+type periodGuard struct {
+	w io.Writer
+}
+
+func (g periodGuard) Write(p []byte) (int, error) {
+	if bytes.IndexByte(p, '.') >= 0 {
+		return 0, ErrMalformedStream
+	}
+	return g.w.Write(p)
+}
@@ -0,0 +1,131 @@
+package jws
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+func testStreamOptions(t *testing.T) []*Options {
+	hs := BlankOptions()
+	hs.Algorithm = jwa.HS256
+	hs.SignID = "hs"
+	if err := hs.LoadSecret(testMCKey); err != nil {
+		t.Fatal(err)
+	}
+
+	return []*Options{
+		hs,
+		NewOptions(jwa.RS256, testRSAKey, testRSAPubKey, "rs"),
+		NewOptions(jwa.PS384, testRSAKey, testRSAPubKey, "ps"),
+		NewOptions(jwa.ES512, testP521Key, testP521PubKey, "es"),
+	}
+}
+
+func Test_Stream_SignVerify(t *testing.T) {
+
+	//Large enough to need several reads, not multiple of 3 to test the encoding tail.
+	payload := make([]byte, 1<<20+1)
+	rand.Read(payload)
+
+	for _, opt := range testStreamOptions(t) {
+		var token bytes.Buffer
+		if err := SignStream(&token, map[string]interface{}{"cty": "backup"}, bytes.NewReader(payload), opt); err != nil {
+			t.Fatalf("%s: %v", opt.SignID, err)
+		}
+
+		var out bytes.Buffer
+		header, err := VerifyStream(bytes.NewReader(token.Bytes()), &out, opt)
+		if err != nil {
+			t.Fatalf("%s: %v", opt.SignID, err)
+		}
+
+		if !bytes.Equal(out.Bytes(), payload) {
+			t.Errorf("%s: different payloads", opt.SignID)
+		}
+
+		if header["cty"] != "backup" || header["kid"] != opt.SignID {
+			t.Errorf("%s: unexpected header %v", opt.SignID, header)
+		}
+
+		//Flip a payload character
+		var altered = token.Bytes()
+		var i = bytes.IndexByte(altered, '.') + 10
+		altered[i] ^= 'A' ^ 'B'
+		if _, err = VerifyStream(bytes.NewReader(altered), io.Discard, opt); err == nil {
+			t.Errorf("%s: missed error", opt.SignID)
+		}
+	}
+}
+
+//A streamed token is an ordinary compact JWS
+func Test_Stream_MatchesSign(t *testing.T) {
+	opt := testStreamOptions(t)[0]
+
+	token := jwt.NewJWT()
+	token.SetIssuer("fido")
+	if err := Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	compact, err := token.CompactSerialization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if _, err = VerifyStream(bytes.NewReader(compact), &out, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != `{"iss":"fido"}` {
+		t.Errorf("Unexpected payload %s", out.String())
+	}
+}
+
+func Test_Stream_Unencoded(t *testing.T) {
+	opt := testStreamOptions(t)[1]
+	header := map[string]interface{}{"b64": false, "crit": []string{"b64"}}
+
+	var token bytes.Buffer
+	if err := SignStream(&token, header, strings.NewReader("no separators here"), opt); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(token.String(), ".no separators here.") {
+		t.Errorf("Expected an unencoded payload: %s", token.String())
+	}
+
+	var out bytes.Buffer
+	if _, err := VerifyStream(&token, &out, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SignStream(io.Discard, header, strings.NewReader("a.b"), opt); err == nil {
+		t.Error("missed error")
+	}
+}
+
+func Test_Stream_Malformed(t *testing.T) {
+	opt := testStreamOptions(t)[0]
+
+	var tokens = []string{
+		``,
+		`eyJhbGciOiJIUzI1NiJ9`,
+		`eyJhbGciOiJIUzI1NiJ9.cGF5bG9hZA`,
+		`eyJhbGciOiJIUzI1NiJ9.cGF5bG9hZA.`,
+		`eyJhbGciOiJIUzI1NiJ9.cGF5bG9hZA.c2ln.c2ln`,
+		strings.Repeat("a", maxStreamHeader+1) + `.cGF5bG9hZA.c2ln`,
+	}
+
+	for i, token := range tokens {
+		if _, err := VerifyStream(strings.NewReader(token), io.Discard, opt); err == nil {
+			t.Errorf("%d: missed error", i)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package jws
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+//DefaultTokenLifetime is the lifetime of the tokens minted by a Transport with no Lifetime.
+const DefaultTokenLifetime = 5 * time.Minute
+
+//Transport is an http.RoundTripper that sets a signed bearer token in the Authorization
+//header of every request. The tokens carry "iss", "sub", "aud", "iat", "exp" and "jti",
+//and are reused for the same audience until they are about to expire.
+//It's safe for concurrent use.
+type Transport struct {
+	//Base sends the requests. http.DefaultTransport is used if nil.
+	Base http.RoundTripper
+	//Options returns the Options that sign the tokens, such as the Options method of a KeyManager.
+	Options func() (*Options, error)
+
+	//Issuer and Subject are the "iss" and "sub" claims, if not empty.
+	Issuer  string
+	Subject string
+	//Audience returns the "aud" claim for a request. The scheme and host of
+	//the target, such as https://api.example.com, are used if nil.
+	Audience func(r *http.Request) string
+	//Claims are added to every token.
+	Claims jwt.Claims
+
+	//Lifetime is how long the tokens are valid. DefaultTokenLifetime is used if zero.
+	Lifetime time.Duration
+	//Refresh is how long before their expiration the tokens are replaced.
+	//A fifth of the lifetime is used if zero.
+	Refresh time.Duration
+	//Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+}
+
+type cachedToken struct {
+	compact string
+	renew   time.Time
+}
+
+//RoundTrip sends a copy of r with the token of its audience.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+
+	var audience = r.URL.Scheme + "://" + r.URL.Host
+	if t.Audience != nil {
+		audience = t.Audience(r)
+	}
+
+	token, err := t.Token(audience)
+	if err != nil {
+		//The body must be closed even on errors: see http.RoundTripper.
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, err
+	}
+
+	//A RoundTripper must not modify the request.
+	var signed = r.Clone(r.Context())
+	signed.Header.Set("Authorization", "Bearer "+token)
+
+	var base = t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
+
+//Token returns the compact serialization of a token for audience, from the cache
+//if it's not about to expire.
+func (t *Transport) Token(audience string) (string, error) {
+
+	var now = time.Now()
+	if t.Now != nil {
+		now = t.Now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if cached, ok := t.tokens[audience]; ok && now.Before(cached.renew) {
+		return cached.compact, nil
+	}
+
+	var lifetime = t.Lifetime
+	if lifetime <= 0 {
+		lifetime = DefaultTokenLifetime
+	}
+	var refresh = t.Refresh
+	if refresh <= 0 || refresh >= lifetime {
+		refresh = lifetime / 5
+	}
+
+	compact, err := t.mint(audience, now, lifetime)
+	if err != nil {
+		return "", err
+	}
+
+	if t.tokens == nil {
+		t.tokens = make(map[string]cachedToken)
+	}
+	t.tokens[audience] = cachedToken{compact: compact, renew: now.Add(lifetime - refresh)}
+	return compact, nil
+}
+
+//mint signs a new token for audience.
+func (t *Transport) mint(audience string, now time.Time, lifetime time.Duration) (string, error) {
+
+	if t.Options == nil {
+		return "", jwa.ErrInvalidInput
+	}
+
+	opt, err := t.Options()
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := jwt.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	var token = jwt.NewJWT()
+	for k, v := range t.Claims {
+		token.Payload[k] = v
+	}
+	if t.Issuer != "" {
+		token.SetIssuer(t.Issuer)
+	}
+	if t.Subject != "" {
+		token.SetSubject(t.Subject)
+	}
+	if audience != "" {
+		token.Payload["aud"] = audience
+	}
+	token.SetIssuedAt(now.Unix())
+	token.SetExpirationTime(now.Add(lifetime).Unix())
+	token.SetTokenID(jti)
+
+	if err = Sign(token, opt); err != nil {
+		return "", err
+	}
+
+	compact, err := token.CompactSerialization()
+	if err != nil {
+		return "", err
+	}
+	return string(compact), nil
+}
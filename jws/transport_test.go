@@ -0,0 +1,113 @@
+package jws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+func Test_Transport_RoundTrip(t *testing.T) {
+
+	m := NewKeyManager(jwa.ES256, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var received []*jwt.JWT
+	server := httptest.NewServer((&Bearer{Verifier: m, AnyAudience: true}).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := TokenFromContext(r.Context())
+		received = append(received, token)
+	})))
+	defer server.Close()
+
+	var now = time.Now()
+	transport := &Transport{
+		Options:  m.Options,
+		Issuer:   "client",
+		Subject:  "client",
+		Claims:   jwt.Claims{"scope": "read"},
+		Lifetime: 5 * time.Minute,
+		Refresh:  time.Minute,
+		Now:      func() time.Time { return now },
+	}
+	client := &http.Client{Transport: transport}
+
+	get := func() {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("Expected %d, found %d", http.StatusOK, res.StatusCode)
+		}
+		if req.Header.Get("Authorization") != "" {
+			t.Error("The request of the caller was modified")
+		}
+	}
+
+	get()
+	now = now.Add(3 * time.Minute)
+	get()
+	now = now.Add(90 * time.Second)
+	get()
+
+	if len(received) != 3 {
+		t.Fatalf("Expected 3 requests, found %d", len(received))
+	}
+
+	first := received[0]
+	if first.Issuer() != "client" || first.Audience()[0] != server.URL || first.Payload["scope"] != "read" {
+		t.Errorf("Unexpected claims: %v", first.Payload)
+	}
+	if first.ExpirationTime()-first.IssuedAt() != 300 {
+		t.Errorf("Unexpected lifetime: %v", first.Payload)
+	}
+
+	//The token is reused until a minute before its expiration.
+	if received[1].TokenID() != first.TokenID() {
+		t.Error("Expected the cached token to be reused")
+	}
+	if received[2].TokenID() == first.TokenID() {
+		t.Error("Expected a new token close to the expiration")
+	}
+}
+
+func Test_Transport_Audience(t *testing.T) {
+
+	opt := BlankOptions()
+	opt.Algorithm = jwa.HS256
+	opt.LoadSecret(testMCKey)
+
+	transport := &Transport{
+		Options: func() (*Options, error) { return opt, nil },
+		Audience: func(r *http.Request) string {
+			return "urn:" + r.URL.Hostname()
+		},
+	}
+
+	a, err := transport.Token("urn:a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := transport.Token("urn:b")
+	if a == b {
+		t.Error("Expected a token per audience")
+	}
+
+	token, err := jwt.Deserialize([]byte(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Verify(&token, opt); err != nil {
+		t.Error(err)
+	}
+	if aud := token.Payload["aud"]; aud != "urn:a" {
+		t.Errorf("Unexpected audience: %v", aud)
+	}
+}
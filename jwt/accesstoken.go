@@ -0,0 +1,138 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"github.com/vegaj/JOSE/b64"
+)
+
+const (
+	//TypeJWT is the "typ" of plain JWTs: https://tools.ietf.org/html/rfc7519#section-5.1
+	TypeJWT = `JWT`
+	//TypeAccessToken is the "typ" of the JWT access tokens: https://tools.ietf.org/html/rfc9068#section-2.1
+	TypeAccessToken = `at+jwt`
+
+	typeHeader = `typ`
+	scopek     = `scope`
+	clientIDk  = `client_id`
+)
+
+//NewTokenID returns a random "jti": https://tools.ietf.org/html/rfc7519#section-4.1.7
+func NewTokenID() (string, error) {
+	var id = make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return b64.EncodeURL(id), nil
+}
+
+//TypeMatches reports whether typ, the value of a "typ" header, is the media type expected.
+//They are compared ignoring case, and the "application/" prefix may be omitted from both:
+//https://tools.ietf.org/html/rfc7515#section-4.1.9
+func TypeMatches(typ, expected string) bool {
+	const prefix = `application/`
+
+	var normalize = func(s string) string {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if strings.HasPrefix(s, prefix) && !strings.Contains(s[len(prefix):], "/") {
+			s = s[len(prefix):]
+		}
+		return s
+	}
+	return typ != "" && normalize(typ) == normalize(expected)
+}
+
+//AccessToken describes a JWT access token of RFC 9068: https://tools.ietf.org/html/rfc9068#section-2.2
+type AccessToken struct {
+	//Issuer is the "iss", the authorization server.
+	Issuer string
+	//Subject is the "sub", the resource owner or, with client credentials, the client.
+	Subject string
+	//ClientID is the "client_id" of the client the token was issued to.
+	ClientID string
+	//Audience is the "aud", the resource servers the token is intended for.
+	Audience []string
+	//Scopes are written into the "scope" claim, if any.
+	Scopes []string
+	//Lifetime is the time from IssuedAt until "exp".
+	Lifetime time.Duration
+	//IssuedAt is the "iat". The current time is used if zero.
+	IssuedAt time.Time
+}
+
+//NewJWT returns an unsigned token with the "typ" header and the claims of a, ready to be signed
+//by jws.Sign. Every token gets a random "jti".
+func (a AccessToken) NewJWT() (*JWT, error) {
+
+	if a.Issuer == "" || a.Subject == "" || a.ClientID == "" || len(a.Audience) == 0 || a.Lifetime <= 0 {
+		return nil, ErrMissingClaim
+	}
+
+	var iat = a.IssuedAt
+	if iat.IsZero() {
+		iat = time.Now()
+	}
+
+	jti, err := NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	var token = NewJWT()
+	token.Header[typeHeader] = TypeAccessToken
+	token.SetIssuer(a.Issuer)
+	token.SetSubject(a.Subject)
+	token.Payload[clientIDk] = a.ClientID
+	if len(a.Audience) == 1 {
+		token.Payload[audiencek] = a.Audience[0]
+	} else {
+		token.SetAudience(a.Audience)
+	}
+	token.SetIssuedAt(iat.Unix())
+	token.SetExpirationTime(iat.Add(a.Lifetime).Unix())
+	token.SetTokenID(jti)
+	if len(a.Scopes) != 0 {
+		token.Payload[scopek] = strings.Join(a.Scopes, " ")
+	}
+	return token, nil
+}
+
+//Scopes returns the values of the space separated "scope" claim: https://tools.ietf.org/html/rfc8693#section-4.2
+func (jwt JWT) Scopes() []string {
+	scope, _ := jwt.Payload[scopek].(string)
+	return strings.Fields(scope)
+}
+
+//HasScopes reports whether the "scope" claim has every one of scopes.
+func (jwt JWT) HasScopes(scopes ...string) bool {
+	var granted = jwt.Scopes()
+	for _, s := range scopes {
+		if !contains(granted, s) {
+			return false
+		}
+	}
+	return true
+}
+
+//ValidateAccessToken checks a JWT access token as a resource server must:
+//https://tools.ietf.org/html/rfc9068#section-4
+//The "typ" of its header must be "at+jwt", its signature must have been verified already so
+//its header is the protected one, and v must set the expected Issuer and Audience. The claims
+//required by the profile must be present, and the ones in v are checked as Validate does.
+func ValidateAccessToken(jwt JWT, v Validator) error {
+
+	typ, _ := jwt.Header[typeHeader].(string)
+	if !TypeMatches(typ, TypeAccessToken) {
+		return ErrInvalidType
+	}
+
+	if v.Issuer == "" || v.Audience == "" {
+		return ErrMissingClaim
+	}
+
+	var required = []string{issuerk, expirationk, audiencek, subjectk, clientIDk, issuedAtk, tokenIDk}
+	v.Required = append(required, v.Required...)
+	return v.Validate(jwt)
+}
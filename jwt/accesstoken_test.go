@@ -0,0 +1,87 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_TypeMatches(t *testing.T) {
+
+	var tests = []struct {
+		typ, expected string
+		match         bool
+	}{
+		{"at+jwt", "at+jwt", true},
+		{"AT+JWT", "at+jwt", true},
+		{"application/at+jwt", "at+jwt", true},
+		{"at+jwt", "application/at+jwt", true},
+		{"Application/AT+JWT", "at+jwt", true},
+		{"JWT", "at+jwt", false},
+		{"", "at+jwt", false},
+		{"application/at+jwt/x", "at+jwt/x", false},
+		{"text/at+jwt", "at+jwt", false},
+	}
+
+	for _, tt := range tests {
+		if TypeMatches(tt.typ, tt.expected) != tt.match {
+			t.Errorf("%q, %q: Expected %v", tt.typ, tt.expected, tt.match)
+		}
+	}
+}
+
+func Test_AccessToken(t *testing.T) {
+
+	var now = time.Unix(1600000000, 0)
+	profile := AccessToken{
+		Issuer:   "https://as.example.com",
+		Subject:  "5ba552d67",
+		ClientID: "s6BhdRkqt3",
+		Audience: []string{"https://rs.example.com"},
+		Scopes:   []string{"openid", "profile", "reademail"},
+		Lifetime: time.Hour,
+		IssuedAt: now,
+	}
+
+	token, err := profile.NewJWT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Header["typ"] != TypeAccessToken || token.ExpirationTime() != now.Unix()+3600 || token.TokenID() == "" {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+	//A single audience is written as a string, and still read as a list.
+	if aud := token.Audience(); len(aud) != 1 || aud[0] != "https://rs.example.com" {
+		t.Errorf("Unexpected audience: %v", aud)
+	}
+
+	if !token.HasScopes("profile", "openid") || token.HasScopes("admin") {
+		t.Errorf("Unexpected scopes: %v", token.Scopes())
+	}
+
+	var v = Validator{Issuer: "https://as.example.com", Audience: "https://rs.example.com", Now: func() time.Time { return now }}
+	if err = ValidateAccessToken(*token, v); err != nil {
+		t.Error(err)
+	}
+
+	//An ID Token, or any other JWT, is not an access token.
+	token.Header["typ"] = TypeJWT
+	if err = ValidateAccessToken(*token, v); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("Expected %s, found %v", ErrInvalidType, err)
+	}
+	token.Header["typ"] = "application/at+jwt"
+
+	delete(token.Payload, "client_id")
+	var missing *ValidationError
+	if err = ValidateAccessToken(*token, v); !errors.As(err, &missing) || missing.Claim != "client_id" {
+		t.Errorf("Expected a missing client_id, found %v", err)
+	}
+
+	if err = ValidateAccessToken(*token, Validator{}); !errors.Is(err, ErrMissingClaim) {
+		t.Errorf("Expected %s, found %v", ErrMissingClaim, err)
+	}
+
+	if _, err = (AccessToken{Issuer: "https://as.example.com"}).NewJWT(); !errors.Is(err, ErrMissingClaim) {
+		t.Errorf("Expected %s, found %v", ErrMissingClaim, err)
+	}
+}
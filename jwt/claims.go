@@ -67,8 +67,10 @@ func (jwt *JWT) DelSubject() {
 //  In the general case, the "aud" value is an array of case-
 // sensitive strings, each containing a StringOrURI value.
 // OPTIONAL | [StringOrURI] | 'aud'
+//A single string is returned as a list of one, and nil if the claim is missing or malformed.
 func (jwt JWT) Audience() []string {
-	return jwt.Payload[audiencek].([]string)
+	aud, _ := StringList(jwt.Payload[audiencek])
+	return aud
 }
 
 //SetAudience setter method for this claim.
@@ -0,0 +1,50 @@
+package jwt
+
+import (
+	"errors"
+)
+
+var (
+	//ErrNotImplemented means that the requested serialization is not supported yet.
+	ErrNotImplemented = errors.New("not implemented")
+	//ErrUnencodedPayload means that an unencoded payload cannot be carried by the compact serialization.
+	ErrUnencodedPayload = errors.New("unencoded payload contains a period")
+	//ErrMalformedToken means that the serialization given to Deserialize cannot be parsed.
+	ErrMalformedToken = errors.New("malformed token")
+
+	//ErrMissingClaim means that a required claim is not present.
+	ErrMissingClaim = errors.New("missing claim")
+	//ErrInvalidClaim means that the claim value has not the expected type.
+	ErrInvalidClaim = errors.New("invalid claim")
+	//ErrExpired means that the current time is after the "exp" claim.
+	ErrExpired = errors.New("token is expired")
+	//ErrNotValidYet means that the current time is before the "nbf" claim.
+	ErrNotValidYet = errors.New("token is not valid yet")
+	//ErrInvalidIssuer means that the "iss" claim is not the expected one.
+	ErrInvalidIssuer = errors.New("invalid issuer")
+	//ErrInvalidSubject means that the "sub" claim is not the expected one.
+	ErrInvalidSubject = errors.New("invalid subject")
+	//ErrInvalidType means that the "typ" header is not the expected one.
+	ErrInvalidType = errors.New("invalid token type")
+	//ErrInvalidAudience means that the token is not intended for the expected audience.
+	ErrInvalidAudience = errors.New("invalid audience")
+)
+
+//ValidationError is returned when the claims of a token are rejected.
+//It carries the name of the failing claim and wraps the reason,
+//so it can be checked with errors.Is(err, ErrExpired) or errors.As.
+type ValidationError struct {
+	//Claim is the name of the rejected claim, such as "exp" or "aud".
+	Claim string
+	//Err is the reason of the failure.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return "claim " + e.Claim + ": " + e.Err.Error()
+}
+
+//Unwrap returns the reason of the failure.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
@@ -1,8 +1,10 @@
 package jwt
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
+	"reflect"
+	"strings"
 
 	"github.com/vegaj/JOSE/b64"
 )
@@ -17,6 +19,11 @@ type JWT struct {
 	Header     map[string]interface{} `json:"headers"`
 	Payload    Claims                 `json:"payload"`
 	Signatures []Signature            `json:"signatures"`
+
+	//RawPayload is the payload as it was received by Deserialize. Re-encoding Payload
+	//may not give back the bytes that were signed, so they are kept while Payload
+	//is left unchanged. See PayloadJSON.
+	RawPayload []byte `json:"-"`
 }
 
 //Signature struct contains a JWE header the signature / MAC algorithm used,
@@ -29,9 +36,136 @@ type Signature struct {
 }
 
 //Deserialize returns a new JWT with the information found in data.
-//The data is expected to be a compact or a JSON serialization.
+//The data is expected to be a compact or a JSON serialization, and only the compact
+//one is supported yet: <HEADER>.<PAYLOAD> or <PROTECTED>.<PAYLOAD>.<SIGNATURE>.
+//The signature is not verified, and the payload is kept in RawPayload.
 func Deserialize(data []byte) (JWT, error) {
-	return JWT{}, errors.New("not implemented")
+
+	data = bytes.TrimSpace(data)
+	if len(data) != 0 && data[0] == '{' {
+		return JWT{}, ErrNotImplemented
+	}
+
+	var parts = strings.Split(string(data), ".")
+	if len(parts) != 2 && len(parts) != 3 {
+		return JWT{}, ErrMalformedToken
+	}
+
+	var header, signatureHeader map[string]interface{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return JWT{}, err
+	}
+	//Each one gets its own map, so editing one doesn't edit the other.
+	decodeSegment(parts[0], &signatureHeader)
+
+	unencoded, err := UnencodedPayload(header)
+	if err != nil {
+		return JWT{}, err
+	}
+
+	var raw = []byte(parts[1])
+	if !unencoded || len(parts) == 2 {
+		var err error
+		if raw, err = b64.DecodeURLStrict(parts[1]); err != nil {
+			return JWT{}, ErrMalformedToken
+		}
+	}
+
+	payload, err := decodeClaims(raw)
+	if err != nil {
+		return JWT{}, ErrMalformedToken
+	}
+
+	var jwt = JWT{
+		Header:     header,
+		Payload:    payload,
+		Signatures: make([]Signature, 0, 1),
+		RawPayload: raw,
+	}
+
+	if len(parts) == 3 {
+		if _, err := b64.DecodeURLStrict(parts[2]); err != nil || parts[2] == "" {
+			return JWT{}, ErrMalformedToken
+		}
+		jwt.Signatures = append(jwt.Signatures, Signature{
+			Header:    signatureHeader,
+			Protected: parts[0],
+			Signature: parts[2],
+		})
+	}
+	return jwt, nil
+}
+
+//UnencodedPayload reports whether header asks for the Unencoded Payload Option.
+//"b64" is only honored when it's also listed in "crit": https://tools.ietf.org/html/rfc7797#section-6
+//A header with "b64" that isn't listed, or that isn't a boolean, is ErrMalformedToken.
+func UnencodedPayload(header map[string]interface{}) (bool, error) {
+
+	value, ok := header["b64"]
+	if !ok {
+		return false, nil
+	}
+
+	var listed bool
+	switch crit := header["crit"].(type) {
+	case []string:
+		for _, name := range crit {
+			listed = listed || name == "b64"
+		}
+	case []interface{}:
+		for _, name := range crit {
+			listed = listed || name == "b64"
+		}
+	}
+
+	encoded, ok := value.(bool)
+	if !ok || !listed {
+		return false, ErrMalformedToken
+	}
+	return !encoded, nil
+}
+
+//decodeSegment decodes a base64url encoded JSON object into v.
+func decodeSegment(segment string, v *map[string]interface{}) error {
+
+	raw, err := b64.DecodeURLStrict(segment)
+	if err != nil {
+		return ErrMalformedToken
+	}
+
+	if err = json.Unmarshal(raw, v); err != nil || *v == nil {
+		return ErrMalformedToken
+	}
+	return nil
+}
+
+//decodeClaims decodes a JSON object keeping its numbers as json.Number, as ExtractTimeField expects.
+func decodeClaims(raw []byte) (Claims, error) {
+
+	var decoder = json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var claims Claims
+	if err := decoder.Decode(&claims); err != nil || claims == nil {
+		return nil, ErrMalformedToken
+	}
+	if decoder.More() {
+		return nil, ErrMalformedToken
+	}
+	return claims, nil
+}
+
+//PayloadJSON returns the JSON encoding of the payload: RawPayload while it still
+//decodes to Payload, so the signatures of a deserialized token can be verified,
+//or the encoding of Payload otherwise.
+func (jwt JWT) PayloadJSON() ([]byte, error) {
+
+	if jwt.RawPayload != nil {
+		if received, err := decodeClaims(jwt.RawPayload); err == nil && reflect.DeepEqual(received, jwt.Payload) {
+			return jwt.RawPayload, nil
+		}
+	}
+	return json.Marshal(jwt.Payload)
 }
 
 //NewJWT will create an empty JWT.
@@ -46,53 +180,52 @@ func NewJWT() *JWT {
 //CompactSerialization will returns a serialization of the current jwt.
 //This serialization will be in the form of:
 //<HEADER>.<PAYLOAD> if it's a not signed JWT.
-//<HEADER>.<PAYLOAD>.<SIGNATURE> if it's a JWS.
+//<PROTECTED>.<PAYLOAD>.<SIGNATURE> if it's a JWS, using its first signature.
 //TODO add support for JWE.
 func (jwt JWT) CompactSerialization() ([]byte, error) {
 
-	var signature *Signature
-	if len(jwt.Signatures) > 0 {
-		signature = &jwt.Signatures[0]
-		//TODO We must actually combine both headers.
-		jwt.Header["alg"] = signature.Header["alg"]
-		jwt.Header["kid"] = signature.Header["kid"]
-	} else {
-		signature = nil
-	}
-
-	headerJSON, err := json.Marshal(jwt.Header)
-	if err != nil {
-		return nil, err
-	}
-	payloadJSON, err := json.Marshal(jwt.Payload)
+	payloadJSON, err := jwt.PayloadJSON()
 	if err != nil {
 		return nil, err
 	}
 
-	header64 := b64.EncodeURL(headerJSON)
-	payload64 := b64.EncodeURL(payloadJSON)
+	if len(jwt.Signatures) == 0 {
+		headerJSON, err := json.Marshal(jwt.Header)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(b64.EncodeURL(headerJSON) + "." + b64.EncodeURL(payloadJSON)), nil
+	}
 
-	var message string
+	var signature = jwt.Signatures[0]
+	var payload = b64.EncodeURL(payloadJSON)
 
-	if signature == nil {
-		message = header64 + "." + payload64
-	} else {
-		message = header64 + "." + payload64 + "." + signature.Signature
+	//Unencoded payloads can only be carried if they don't break the serialization.
+	//https://tools.ietf.org/html/rfc7797#section-5.2
+	unencoded, err := UnencodedPayload(signature.Header)
+	if err != nil {
+		return nil, err
+	}
+	if unencoded {
+		if bytes.ContainsRune(payloadJSON, '.') {
+			return nil, ErrUnencodedPayload
+		}
+		payload = string(payloadJSON)
 	}
 
-	return []byte(message), nil
+	return []byte(signature.Protected + "." + payload + "." + signature.Signature), nil
 }
 
 //JSONSerialization returns a transmisible and storable representation of
 //this object in JSON format. This serialization is described:
 //Here in the case of a JWS: https://tools.ietf.org/html/rfc7515#section-7.2
 func (jwt JWT) JSONSerialization() ([]byte, error) {
-	return nil, errors.New("not implemented")
+	return nil, ErrNotImplemented
 }
 
 //JSONFlatSerialization is used as a lighter weight JSON representation for a JWT.
 //This representation allows only one signature.
 //It's described here: https://tools.ietf.org/html/rfc7515#section-7.2.2
 func (jwt JWT) JSONFlatSerialization() ([]byte, error) {
-	return nil, errors.New("not implemented")
+	return nil, ErrNotImplemented
 }
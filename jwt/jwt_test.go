@@ -0,0 +1,139 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vegaj/JOSE/b64"
+)
+
+func Test_Deserialize_Compact(t *testing.T) {
+
+	//The claims are neither in the order nor in the spacing json.Marshal would write them.
+	var payload = `{"sub":"fido", "iss":"pepe", "exp":1300819380}`
+	var protected = b64.EncodeURL([]byte(`{"alg":"HS256","kid":"k1"}`))
+	var compact = protected + "." + b64.EncodeURL([]byte(payload)) + "." + b64.EncodeURL([]byte("signature"))
+
+	token, err := Deserialize([]byte(compact))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.Issuer() != "pepe" || token.Subject() != "fido" || token.ExpirationTime() != 1300819380 {
+		t.Errorf("Unexpected claims: %v", token.Payload)
+	}
+	if len(token.Signatures) != 1 || token.Signatures[0].Header["kid"] != "k1" || token.Header["alg"] != "HS256" {
+		t.Errorf("Unexpected signatures: %+v", token.Signatures)
+	}
+
+	//The claims decoded from JSON have no []string values.
+	single, _ := Deserialize([]byte(protected + "." + b64.EncodeURL([]byte(`{"aud":"api"}`)) + ".c2ln"))
+	list, _ := Deserialize([]byte(protected + "." + b64.EncodeURL([]byte(`{"aud":["api","web"]}`)) + ".c2ln"))
+	if aud := single.Audience(); len(aud) != 1 || aud[0] != "api" {
+		t.Errorf("Unexpected audience: %v", aud)
+	}
+	if aud := list.Audience(); len(aud) != 2 || aud[1] != "web" {
+		t.Errorf("Unexpected audience: %v", aud)
+	}
+	if aud := token.Audience(); aud != nil {
+		t.Errorf("Unexpected audience: %v", aud)
+	}
+
+	raw, _ := token.PayloadJSON()
+	if string(raw) != payload {
+		t.Errorf("Expected the received payload, found %s", raw)
+	}
+
+	serialized, _ := token.CompactSerialization()
+	if string(serialized) != compact {
+		t.Errorf("Expected %s, found %s", compact, serialized)
+	}
+
+	//Once the claims change, the received payload no longer describes them.
+	token.SetIssuer("mallory")
+	if raw, _ = token.PayloadJSON(); string(raw) == payload {
+		t.Error("The received payload was kept after a change of the claims")
+	}
+}
+
+func Test_Deserialize_Unencoded(t *testing.T) {
+
+	var payload = `{"iss":"pepe"}`
+	var protected = b64.EncodeURL([]byte(`{"alg":"HS256","b64":false,"crit":["b64"]}`))
+
+	token, err := Deserialize([]byte(protected + "." + payload + "." + b64.EncodeURL([]byte("signature"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Issuer() != "pepe" || string(token.RawPayload) != payload {
+		t.Errorf("Unexpected payload: %s", token.RawPayload)
+	}
+
+	//"b64" is ignored by the recipients that don't understand it, so it must be critical.
+	for _, header := range []string{`{"alg":"HS256","b64":false}`, `{"alg":"HS256","b64":true}`, `{"alg":"HS256","b64":false,"crit":["exp"]}`} {
+		var protected = b64.EncodeURL([]byte(header))
+		if _, err = Deserialize([]byte(protected + "." + payload + "." + b64.EncodeURL([]byte("signature")))); !errors.Is(err, ErrMalformedToken) {
+			t.Errorf("%s: Expected %s, found %v", header, ErrMalformedToken, err)
+		}
+	}
+
+	token.Signatures[0].Header = map[string]interface{}{"alg": "HS256", "b64": false}
+	if _, err = token.CompactSerialization(); !errors.Is(err, ErrMalformedToken) {
+		t.Errorf("Expected %s, found %v", ErrMalformedToken, err)
+	}
+}
+
+func Test_UnencodedPayload(t *testing.T) {
+
+	var tests = []struct {
+		header    map[string]interface{}
+		unencoded bool
+		err       error
+	}{
+		{map[string]interface{}{"alg": "HS256"}, false, nil},
+		{map[string]interface{}{"b64": false, "crit": []string{"b64"}}, true, nil},
+		{map[string]interface{}{"b64": false, "crit": []interface{}{"exp", "b64"}}, true, nil},
+		{map[string]interface{}{"b64": true, "crit": []interface{}{"b64"}}, false, nil},
+		{map[string]interface{}{"b64": false}, false, ErrMalformedToken},
+		{map[string]interface{}{"b64": false, "crit": []string{"exp"}}, false, ErrMalformedToken},
+		{map[string]interface{}{"b64": "false", "crit": []string{"b64"}}, false, ErrMalformedToken},
+	}
+
+	for i, tt := range tests {
+		unencoded, err := UnencodedPayload(tt.header)
+		if unencoded != tt.unencoded || !errors.Is(err, tt.err) {
+			t.Errorf("%d: Expected %v, %v, found %v, %v", i, tt.unencoded, tt.err, unencoded, err)
+		}
+	}
+}
+
+func Test_Deserialize_Malformed(t *testing.T) {
+
+	var header = b64.EncodeURL([]byte(`{"alg":"none"}`))
+	var payload = b64.EncodeURL([]byte(`{"iss":"pepe"}`))
+
+	unsigned, err := Deserialize([]byte(header + "." + payload))
+	if err != nil || len(unsigned.Signatures) != 0 || unsigned.Issuer() != "pepe" {
+		t.Errorf("Unexpected unsigned token: %+v %v", unsigned, err)
+	}
+
+	var tests = []string{
+		"",
+		header,
+		header + "." + payload + ".c2ln.extra",
+		"not base64!." + payload + ".c2ln",
+		b64.EncodeURL([]byte(`[1]`)) + "." + payload + ".c2ln",
+		header + "." + b64.EncodeURL([]byte(`"text"`)) + ".c2ln",
+		header + "." + payload + ".",
+	}
+
+	for _, tt := range tests {
+		if _, err := Deserialize([]byte(tt)); !errors.Is(err, ErrMalformedToken) {
+			t.Errorf("%q: Expected %s, found %v", tt, ErrMalformedToken, err)
+		}
+	}
+
+	if _, err := Deserialize([]byte(`{"payload":""}`)); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("Expected %s, found %v", ErrNotImplemented, err)
+	}
+}
@@ -0,0 +1,136 @@
+package jwt
+
+import (
+	"encoding/json"
+	"time"
+)
+
+//Validator checks the registered claims of a JWT as described here:
+//https://tools.ietf.org/html/rfc7519#section-4.1
+//The zero value only checks the "exp" and "nbf" claims when they are present.
+type Validator struct {
+	//Issuer, when not empty, must be equal to the "iss" claim.
+	Issuer string
+	//Subject, when not empty, must be equal to the "sub" claim.
+	Subject string
+	//Audience, when not empty, must be one of the values of the "aud" claim.
+	Audience string
+	//Required lists the claims that must be present.
+	Required []string
+	//Leeway is the allowed clock skew for the time based claims.
+	Leeway time.Duration
+	//Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+//Validate returns a *ValidationError naming the first claim of jwt that doesn't
+//satisfy v, or nil if all of them do.
+func (v Validator) Validate(jwt JWT) error {
+
+	for _, claim := range v.Required {
+		if _, ok := jwt.Payload[claim]; !ok {
+			return &ValidationError{Claim: claim, Err: ErrMissingClaim}
+		}
+	}
+
+	var now = time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+
+	if value, ok := jwt.Payload[expirationk]; ok {
+		exp, ok := NumericDate(value)
+		if !ok {
+			return &ValidationError{Claim: expirationk, Err: ErrInvalidClaim}
+		}
+		if !now.Add(-v.Leeway).Before(time.Unix(exp, 0)) {
+			return &ValidationError{Claim: expirationk, Err: ErrExpired}
+		}
+	}
+
+	if value, ok := jwt.Payload[notBeforek]; ok {
+		nbf, ok := NumericDate(value)
+		if !ok {
+			return &ValidationError{Claim: notBeforek, Err: ErrInvalidClaim}
+		}
+		if now.Add(v.Leeway).Before(time.Unix(nbf, 0)) {
+			return &ValidationError{Claim: notBeforek, Err: ErrNotValidYet}
+		}
+	}
+
+	if v.Issuer != "" {
+		if iss, _ := jwt.Payload[issuerk].(string); iss != v.Issuer {
+			return &ValidationError{Claim: issuerk, Err: ErrInvalidIssuer}
+		}
+	}
+
+	if v.Subject != "" {
+		if sub, _ := jwt.Payload[subjectk].(string); sub != v.Subject {
+			return &ValidationError{Claim: subjectk, Err: ErrInvalidSubject}
+		}
+	}
+
+	if v.Audience != "" {
+		aud, ok := StringList(jwt.Payload[audiencek])
+		if !ok {
+			return &ValidationError{Claim: audiencek, Err: ErrInvalidAudience}
+		}
+		if !contains(aud, v.Audience) {
+			return &ValidationError{Claim: audiencek, Err: ErrInvalidAudience}
+		}
+	}
+
+	return nil
+}
+
+//NumericDate returns the seconds since the epoch held by a claim value, whether it was set
+//by this library or decoded from JSON: https://tools.ietf.org/html/rfc7519#section-2
+func NumericDate(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, true
+		}
+		if f, err := v.Float64(); err == nil {
+			return int64(f), true
+		}
+	}
+	return 0, false
+}
+
+//StringList returns the values of a claim that can be either a single string
+//or an array of strings, as the "aud" claim.
+func StringList(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, true
+	case []string:
+		return v, true
+	case []interface{}:
+		var list = make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, false
+			}
+			list = append(list, s)
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Validate_Time(t *testing.T) {
+	var now = time.Unix(1000000, 0)
+	var v = Validator{Now: func() time.Time { return now }, Leeway: 10 * time.Second}
+
+	token := NewJWT()
+	token.SetExpirationTime(now.Unix() + 60)
+	token.SetNotBefore(now.Unix() - 60)
+	if err := v.Validate(*token); err != nil {
+		t.Error(err)
+	}
+
+	//Within the leeway
+	token.SetExpirationTime(now.Unix() - 5)
+	token.SetNotBefore(now.Unix() + 5)
+	if err := v.Validate(*token); err != nil {
+		t.Error(err)
+	}
+
+	token.SetExpirationTime(now.Unix() - 10)
+	var verr *ValidationError
+	if err := v.Validate(*token); !errors.As(err, &verr) || verr.Claim != "exp" || !errors.Is(err, ErrExpired) {
+		t.Errorf("Expected %s on exp, found %v", ErrExpired, err)
+	}
+
+	token.SetExpirationTime(now.Unix() + 60)
+	token.SetNotBefore(now.Unix() + 11)
+	if err := v.Validate(*token); !errors.As(err, &verr) || verr.Claim != "nbf" || !errors.Is(err, ErrNotValidYet) {
+		t.Errorf("Expected %s on nbf, found %v", ErrNotValidYet, err)
+	}
+
+	//As decoded from JSON
+	token.DelNotBefore()
+	token.Payload["exp"] = json.Number("999000")
+	if err := v.Validate(*token); !errors.Is(err, ErrExpired) {
+		t.Errorf("Expected %s, found %v", ErrExpired, err)
+	}
+
+	token.Payload["exp"] = "tomorrow"
+	if err := v.Validate(*token); !errors.Is(err, ErrInvalidClaim) {
+		t.Errorf("Expected %s, found %v", ErrInvalidClaim, err)
+	}
+}
+
+func Test_Validate_Identifiers(t *testing.T) {
+	var v = Validator{Issuer: "fido", Subject: "pepe", Audience: "api", Required: []string{"jti"}}
+
+	token := NewJWT()
+	token.SetIssuer("fido")
+	token.SetSubject("pepe")
+	token.Payload["aud"] = []interface{}{"app", "api"}
+
+	var verr *ValidationError
+	if err := v.Validate(*token); !errors.As(err, &verr) || verr.Claim != "jti" || !errors.Is(err, ErrMissingClaim) {
+		t.Errorf("Expected %s on jti, found %v", ErrMissingClaim, err)
+	}
+
+	token.SetTokenID("id")
+	if err := v.Validate(*token); err != nil {
+		t.Error(err)
+	}
+
+	token.Payload["aud"] = "api"
+	if err := v.Validate(*token); err != nil {
+		t.Error(err)
+	}
+
+	token.SetAudience([]string{"app"})
+	if err := v.Validate(*token); !errors.Is(err, ErrInvalidAudience) {
+		t.Errorf("Expected %s, found %v", ErrInvalidAudience, err)
+	}
+
+	token.SetAudience([]string{"api"})
+	token.SetIssuer("pepe")
+	if err := v.Validate(*token); !errors.Is(err, ErrInvalidIssuer) {
+		t.Errorf("Expected %s, found %v", ErrInvalidIssuer, err)
+	}
+
+	token.SetIssuer("fido")
+	token.SetSubject("fido")
+	if err := v.Validate(*token); !errors.Is(err, ErrInvalidSubject) {
+		t.Errorf("Expected %s, found %v", ErrInvalidSubject, err)
+	}
+}
@@ -0,0 +1,190 @@
+package oauth
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jws"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+//ClientAssertionType is the "client_assertion_type" of the JWT client assertions:
+//https://tools.ietf.org/html/rfc7523#section-2.2
+const ClientAssertionType = `urn:ietf:params:oauth:client-assertion-type:jwt-bearer`
+
+//DefaultAssertionLifetime is the lifetime of the assertions of a ClientAssertion with no Lifetime,
+//and the longest one accepted by an AssertionVerifier with no MaxLifetime.
+const DefaultAssertionLifetime = time.Minute
+
+//ClientAssertion builds the JWTs that authenticate a client to a token endpoint,
+//the private_key_jwt method of OpenID Connect: https://tools.ietf.org/html/rfc7523#section-3
+type ClientAssertion struct {
+	//ClientID is both the "iss" and the "sub" of the assertion.
+	ClientID string
+	//Audience is the "aud", usually the URL of the token endpoint.
+	Audience string
+	//Lifetime is how long the assertion is valid. DefaultAssertionLifetime is used if zero.
+	Lifetime time.Duration
+	//Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+//Sign returns the compact serialization of a new assertion signed with opt.
+//Every assertion has its own "jti", so it's accepted only once.
+func (a ClientAssertion) Sign(opt *jws.Options) (string, error) {
+
+	if a.ClientID == "" || a.Audience == "" || opt == nil {
+		return "", jwa.ErrInvalidInput
+	}
+
+	var now = time.Now()
+	if a.Now != nil {
+		now = a.Now()
+	}
+	var lifetime = a.Lifetime
+	if lifetime <= 0 {
+		lifetime = DefaultAssertionLifetime
+	}
+
+	jti, err := jwt.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	var token = jwt.NewJWT()
+	token.SetIssuer(a.ClientID)
+	token.SetSubject(a.ClientID)
+	token.Payload["aud"] = a.Audience
+	token.SetIssuedAt(now.Unix())
+	token.SetExpirationTime(now.Add(lifetime).Unix())
+	token.SetTokenID(jti)
+
+	if err = jws.Sign(token, opt); err != nil {
+		return "", err
+	}
+
+	compact, err := token.CompactSerialization()
+	if err != nil {
+		return "", err
+	}
+	return string(compact), nil
+}
+
+//Form returns the parameters that carry a new assertion in a token request:
+//https://tools.ietf.org/html/rfc7523#section-2.2
+func (a ClientAssertion) Form(opt *jws.Options) (url.Values, error) {
+
+	assertion, err := a.Sign(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	return url.Values{
+		"client_id":             {a.ClientID},
+		"client_assertion_type": {ClientAssertionType},
+		"client_assertion":      {assertion},
+	}, nil
+}
+
+//AssertionVerifier checks the client assertions received by a token endpoint:
+//https://tools.ietf.org/html/rfc7523#section-3
+type AssertionVerifier struct {
+	//Audience is the value that the "aud" of the assertions must have, usually the URL of the token endpoint.
+	Audience string
+	//Client returns the Verifier with the keys of a client, such as jws.KeySet over
+	//its registered JWK Set, or an error wrapping ErrUnknownClient.
+	Client func(clientID string) (jws.Verifier, error)
+	//Replay remembers the accepted assertions, so none is accepted twice. It's required.
+	Replay ReplayCache
+	//MaxLifetime is the longest time until "exp" that is accepted.
+	//DefaultAssertionLifetime is used if zero.
+	MaxLifetime time.Duration
+	//Leeway is the allowed clock skew.
+	Leeway time.Duration
+	//Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+//Verify returns the verified assertion. Its "iss" and "sub" are the client ID.
+func (v *AssertionVerifier) Verify(assertion string) (*jwt.JWT, error) {
+
+	if v.Client == nil || v.Replay == nil || v.Audience == "" {
+		return nil, jwa.ErrInvalidInput
+	}
+
+	token, err := jwt.Deserialize([]byte(assertion))
+	if err != nil {
+		return nil, err
+	}
+
+	//The client is not authenticated yet: its claims only choose the keys.
+	clientID, _ := token.Payload["iss"].(string)
+	if sub, _ := token.Payload["sub"].(string); clientID == "" || sub != clientID {
+		return nil, ErrInvalidAssertion
+	}
+
+	verifier, err := v.Client(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifier.Verify(&token); err != nil {
+		return nil, err
+	}
+
+	var now = time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+
+	var validator = jwt.Validator{
+		Issuer:   clientID,
+		Subject:  clientID,
+		Audience: v.Audience,
+		Required: []string{"exp", "jti"},
+		Leeway:   v.Leeway,
+		Now:      func() time.Time { return now },
+	}
+	if err = validator.Validate(token); err != nil {
+		return nil, err
+	}
+
+	var maxLifetime = v.MaxLifetime
+	if maxLifetime <= 0 {
+		maxLifetime = DefaultAssertionLifetime
+	}
+	exp, _ := jwt.NumericDate(token.Payload["exp"])
+	if time.Unix(exp, 0).After(now.Add(maxLifetime + v.Leeway)) {
+		return nil, &jwt.ValidationError{Claim: "exp", Err: ErrInvalidAssertion}
+	}
+
+	jti, ok := token.Payload["jti"].(string)
+	if !ok || jti == "" {
+		return nil, &jwt.ValidationError{Claim: "jti", Err: jwt.ErrInvalidClaim}
+	}
+	//The ids of different clients cannot collide.
+	if v.Replay.Seen(clientID+" "+jti, time.Unix(exp, 0).Add(v.Leeway)) {
+		return nil, ErrReplayedToken
+	}
+	return &token, nil
+}
+
+//VerifyForm verifies the assertion of the parameters of a token request, and returns the client ID.
+//The "client_id" parameter is optional, but it must be the client of the assertion if given.
+func (v *AssertionVerifier) VerifyForm(form url.Values) (string, error) {
+
+	if form.Get("client_assertion_type") != ClientAssertionType {
+		return "", ErrInvalidAssertion
+	}
+
+	token, err := v.Verify(form.Get("client_assertion"))
+	if err != nil {
+		return "", err
+	}
+
+	var clientID = token.Issuer()
+	if id := form.Get("client_id"); id != "" && id != clientID {
+		return "", ErrInvalidAssertion
+	}
+	return clientID, nil
+}
@@ -0,0 +1,151 @@
+package oauth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jws"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+const tokenEndpoint = "https://as.example.com/token"
+
+//clientKey returns the Options that sign for a client, and the JWK Set it registered.
+func clientKey(t *testing.T, alg jwa.Algorithm) (*jws.Options, jwk.Set) {
+
+	key, k, err := jwk.Generate(alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opt := jws.BlankOptions()
+	opt.Algorithm = alg
+	opt.SignID = k.KeyID
+	if err = opt.SetPrivateKey(key); err != nil {
+		t.Fatal(err)
+	}
+	return opt, jwk.Set{Keys: []jwk.Key{k.Public()}}
+}
+
+func Test_Assertion_Verify(t *testing.T) {
+
+	opt, set := clientKey(t, jwa.ES256)
+	other, _ := clientKey(t, jwa.ES256)
+	var now = time.Now()
+
+	verifier := &AssertionVerifier{
+		Audience: tokenEndpoint,
+		Client: func(clientID string) (jws.Verifier, error) {
+			if clientID != "s6BhdRkqt3" {
+				return nil, ErrUnknownClient
+			}
+			return jws.KeySet(func() jwk.Set { return set }), nil
+		},
+		Replay: &MemoryReplayCache{},
+		Now:    func() time.Time { return now },
+	}
+
+	assertion := ClientAssertion{ClientID: "s6BhdRkqt3", Audience: tokenEndpoint, Now: func() time.Time { return now }}
+	form, err := assertion.Form(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientID, err := verifier.VerifyForm(form)
+	if err != nil || clientID != "s6BhdRkqt3" {
+		t.Fatalf("Unexpected client %s: %v", clientID, err)
+	}
+
+	if _, err = verifier.VerifyForm(form); !errors.Is(err, ErrReplayedToken) {
+		t.Errorf("Expected %s, found %v", ErrReplayedToken, err)
+	}
+
+	sign := func(a ClientAssertion, opt *jws.Options) string {
+		signed, err := a.Sign(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	var tests = []struct {
+		name      string
+		assertion string
+		err       error
+	}{
+		{"other key", sign(assertion, other), jws.ErrSignatureNotFound},
+		{"unknown client", sign(ClientAssertion{ClientID: "mallory", Audience: tokenEndpoint}, other), ErrUnknownClient},
+		{"audience", sign(ClientAssertion{ClientID: "s6BhdRkqt3", Audience: "https://other.example.com/token"}, opt), jwt.ErrInvalidAudience},
+		{"lifetime", sign(ClientAssertion{ClientID: "s6BhdRkqt3", Audience: tokenEndpoint, Lifetime: time.Hour}, opt), ErrInvalidAssertion},
+		{"expired", sign(ClientAssertion{ClientID: "s6BhdRkqt3", Audience: tokenEndpoint, Now: func() time.Time { return now.Add(-time.Hour) }}, opt), jwt.ErrExpired},
+		{"garbage", "a.b.c", jwt.ErrMalformedToken},
+	}
+
+	for _, tt := range tests {
+		if _, err := verifier.Verify(tt.assertion); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Expected %s, found %v", tt.name, tt.err, err)
+		}
+	}
+
+	token, err := verifier.Verify(sign(assertion, opt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aud := token.Audience(); len(aud) != 1 || aud[0] != tokenEndpoint {
+		t.Errorf("Unexpected audience: %v", aud)
+	}
+
+	form, _ = assertion.Form(opt)
+	form.Set("client_id", "other")
+	if _, err = verifier.VerifyForm(form); !errors.Is(err, ErrInvalidAssertion) {
+		t.Errorf("Expected %s, found %v", ErrInvalidAssertion, err)
+	}
+}
+
+func Test_Assertion_SubjectMismatch(t *testing.T) {
+
+	opt, set := clientKey(t, jwa.EdDSA)
+
+	token := jwt.NewJWT()
+	token.SetIssuer("s6BhdRkqt3")
+	token.SetSubject("someone else")
+	token.Payload["aud"] = tokenEndpoint
+	token.SetExpirationTime(time.Now().Add(time.Minute).Unix())
+	token.SetTokenID("id")
+	if err := jws.Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+	compact, _ := token.CompactSerialization()
+
+	verifier := &AssertionVerifier{
+		Audience: tokenEndpoint,
+		Client: func(string) (jws.Verifier, error) {
+			return jws.KeySet(func() jwk.Set { return set }), nil
+		},
+		Replay: &MemoryReplayCache{},
+	}
+	if _, err := verifier.Verify(string(compact)); !errors.Is(err, ErrInvalidAssertion) {
+		t.Errorf("Expected %s, found %v", ErrInvalidAssertion, err)
+	}
+}
+
+func Test_MemoryReplayCache(t *testing.T) {
+
+	var now = time.Now()
+	cache := &MemoryReplayCache{Now: func() time.Time { return now }}
+
+	if cache.Seen("a", now.Add(time.Minute)) {
+		t.Error("Unexpected replay of a new id")
+	}
+	if !cache.Seen("a", now.Add(time.Minute)) {
+		t.Error("Expected a replay")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if cache.Seen("a", now.Add(time.Minute)) {
+		t.Error("The expired id was not dropped")
+	}
+}
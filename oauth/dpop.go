@@ -0,0 +1,278 @@
+package oauth
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jws"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+const (
+	//TypeDPoP is the "typ" of the DPoP proofs: https://tools.ietf.org/html/rfc9449#section-4.2
+	TypeDPoP = `dpop+jwt`
+	//DefaultDPoPWindow is how far the "iat" of a proof may be from the current time
+	//for a DPoPVerifier with no Window.
+	DefaultDPoPWindow = time.Minute
+)
+
+var (
+	//ErrInvalidProof means that a DPoP proof is malformed, or doesn't match the request.
+	ErrInvalidProof = errors.New("invalid DPoP proof")
+	//ErrUseDPoPNonce means that the proof lacks a valid server nonce. The server must answer
+	//with the "use_dpop_nonce" error and a fresh DPoP-Nonce: https://tools.ietf.org/html/rfc9449#section-8
+	ErrUseDPoPNonce = errors.New("use_dpop_nonce")
+	//ErrInvalidBinding means that the access token is not bound to the key of the proof.
+	ErrInvalidBinding = errors.New("access token not bound to the DPoP key")
+)
+
+//DPoPProof describes the request that a DPoP proof is made for: https://tools.ietf.org/html/rfc9449#section-4.2
+type DPoPProof struct {
+	//Method is the "htm", the HTTP method of the request.
+	Method string
+	//URI is the "htu", the target URI of the request. Its query and fragment are left out.
+	URI string
+	//AccessToken, when not empty, is hashed into the "ath", for requests to resource servers.
+	AccessToken string
+	//Nonce is the last DPoP-Nonce given by the server, if any.
+	Nonce string
+	//Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+//Sign returns the compact serialization of a new proof signed with opt, whose public key is
+//embedded in the "jwk" header. opt must use an asymmetric algorithm, and it's not modified.
+func (p DPoPProof) Sign(opt *jws.Options) (string, error) {
+
+	if p.Method == "" || opt == nil {
+		return "", jwa.ErrInvalidInput
+	}
+
+	htu, err := normalizeHTU(p.URI)
+	if err != nil {
+		return "", err
+	}
+
+	var now = time.Now()
+	if p.Now != nil {
+		now = p.Now()
+	}
+
+	jti, err := jwt.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	var token = jwt.NewJWT()
+	token.Payload["jti"] = jti
+	token.Payload["htm"] = p.Method
+	token.Payload["htu"] = htu
+	token.SetIssuedAt(now.Unix())
+	if p.AccessToken != "" {
+		token.Payload["ath"] = accessTokenHash(p.AccessToken)
+	}
+	if p.Nonce != "" {
+		token.Payload["nonce"] = p.Nonce
+	}
+
+	var signer = *opt
+	signer.Type = TypeDPoP
+	signer.EmbedKey = true
+	if err = jws.Sign(token, &signer); err != nil {
+		return "", err
+	}
+
+	compact, err := token.CompactSerialization()
+	if err != nil {
+		return "", err
+	}
+	return string(compact), nil
+}
+
+//DPoPVerifier checks the DPoP proofs received by a server: https://tools.ietf.org/html/rfc9449#section-4.3
+type DPoPVerifier struct {
+	//Replay remembers the "jti" of the accepted proofs, so none is accepted twice. It's required.
+	Replay ReplayCache
+	//Window is how far the "iat" may be from the current time. DefaultDPoPWindow is used if zero.
+	Window time.Duration
+	//Nonce, when not nil, reports whether a nonce given by the server is still valid.
+	//The proofs must carry one, or ErrUseDPoPNonce is returned.
+	Nonce func(nonce string) bool
+	//Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+//DPoPRequest is the request that a proof must match.
+type DPoPRequest struct {
+	//Method is the HTTP method of the request.
+	Method string
+	//URI is the URI of the request as the client targeted it, such as https://rs.example.com/resource.
+	URI string
+	//AccessToken, when not empty, is the access token sent with the proof, which the "ath" must match.
+	AccessToken string
+}
+
+//Verify checks proof against r, and returns the proof and the base64url encoded
+//SHA-256 thumbprint of its key, the "jkt" that access tokens are bound to.
+func (v *DPoPVerifier) Verify(proof string, r DPoPRequest) (*jwt.JWT, string, error) {
+
+	if v.Replay == nil {
+		return nil, "", jwa.ErrInvalidInput
+	}
+
+	token, err := jwt.Deserialize([]byte(proof))
+	if err != nil || len(token.Signatures) != 1 {
+		return nil, "", ErrInvalidProof
+	}
+
+	//The algorithm must be asymmetric, as the key is the one of the header.
+	alg, _ := token.Signatures[0].Header["alg"].(string)
+	impl, err := jwa.GetImplementation(jwa.AlgorithmFromName(alg))
+	if err != nil || impl.KeyType == jwk.KeyTypeOct {
+		return nil, "", ErrInvalidProof
+	}
+
+	var opt = jws.BlankOptions()
+	opt.Algorithm = jwa.AlgorithmFromName(alg)
+	opt.ExpectedType = TypeDPoP
+	opt.SignID, _ = token.Signatures[0].Header["kid"].(string)
+	if err = jws.VerifyWithPolicy(&token, opt, jws.HeaderKey); err != nil {
+		return nil, "", err
+	}
+
+	var now = time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	var window = v.Window
+	if window <= 0 {
+		window = DefaultDPoPWindow
+	}
+
+	var validator = jwt.Validator{Required: []string{"jti", "htm", "htu", "iat"}}
+	if err = validator.Validate(token); err != nil {
+		return nil, "", err
+	}
+
+	if htm, _ := token.Payload["htm"].(string); htm != r.Method {
+		return nil, "", &jwt.ValidationError{Claim: "htm", Err: ErrInvalidProof}
+	}
+
+	want, err := normalizeHTU(r.URI)
+	if err != nil {
+		return nil, "", err
+	}
+	if htu, _ := token.Payload["htu"].(string); !htuMatches(htu, want) {
+		return nil, "", &jwt.ValidationError{Claim: "htu", Err: ErrInvalidProof}
+	}
+
+	iat, ok := jwt.NumericDate(token.Payload["iat"])
+	if age := now.Sub(time.Unix(iat, 0)); !ok || age > window || age < -window {
+		return nil, "", &jwt.ValidationError{Claim: "iat", Err: ErrInvalidProof}
+	}
+
+	if r.AccessToken != "" {
+		if ath, _ := token.Payload["ath"].(string); ath != accessTokenHash(r.AccessToken) {
+			return nil, "", &jwt.ValidationError{Claim: "ath", Err: ErrInvalidProof}
+		}
+	}
+
+	if v.Nonce != nil {
+		if nonce, _ := token.Payload["nonce"].(string); nonce == "" || !v.Nonce(nonce) {
+			return nil, "", &jwt.ValidationError{Claim: "nonce", Err: ErrUseDPoPNonce}
+		}
+	}
+
+	jkt, err := proofThumbprint(token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	//The proofs are only kept while their "iat" is within the window.
+	jti, _ := token.Payload["jti"].(string)
+	if jti == "" || v.Replay.Seen(jkt+" "+jti, time.Unix(iat, 0).Add(window)) {
+		return nil, "", ErrReplayedToken
+	}
+	return &token, jkt, nil
+}
+
+//Confirm binds an access token to the key of a DPoP proof, with the "jkt" member of its "cnf" claim:
+//https://tools.ietf.org/html/rfc9449#section-6.1
+func Confirm(token *jwt.JWT, jkt string) {
+	token.Payload["cnf"] = map[string]interface{}{"jkt": jkt}
+}
+
+//CheckConfirmation returns ErrInvalidBinding unless the "cnf" claim of token has jkt,
+//the thumbprint returned by DPoPVerifier.Verify for the proof sent with it.
+func CheckConfirmation(token jwt.JWT, jkt string) error {
+
+	cnf, ok := token.Payload["cnf"].(map[string]interface{})
+	if !ok {
+		return ErrInvalidBinding
+	}
+	if bound, _ := cnf["jkt"].(string); jkt == "" || bound != jkt {
+		return ErrInvalidBinding
+	}
+	return nil
+}
+
+//proofThumbprint returns the base64url encoded SHA-256 thumbprint of the "jwk" of a proof.
+func proofThumbprint(token jwt.JWT) (string, error) {
+
+	pub, err := jws.HeaderKey(token.Signatures[0].Header)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := jwk.FromKey(pub)
+	if err != nil {
+		return "", err
+	}
+
+	thumbprint, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return b64.EncodeURL(thumbprint), nil
+}
+
+//accessTokenHash returns the "ath" of an access token: https://tools.ietf.org/html/rfc9449#section-4.2
+func accessTokenHash(accessToken string) string {
+	var sum = sha256.Sum256([]byte(accessToken))
+	return b64.EncodeURL(sum[:])
+}
+
+//normalizeHTU returns uri without its query and fragment, with the scheme and the host
+//in lower case and without the default port, so equivalent URIs compare equal:
+//https://tools.ietf.org/html/rfc9449#section-4.3
+func normalizeHTU(uri string) (string, error) {
+
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", ErrInvalidProof
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "https" && u.Port() == "443") || (u.Scheme == "http" && u.Port() == "80") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+u.Port())
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery, u.Fragment, u.RawFragment, u.User = "", "", "", nil
+	return u.String(), nil
+}
+
+//htuMatches compares the "htu" of a proof with the normalized URI of the request.
+func htuMatches(htu, want string) bool {
+	got, err := normalizeHTU(htu)
+	return err == nil && got == want
+}
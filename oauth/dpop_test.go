@@ -0,0 +1,157 @@
+package oauth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jws"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+const resourceURI = "https://rs.example.com/resource"
+
+func Test_DPoP_Verify(t *testing.T) {
+
+	opt, _ := clientKey(t, jwa.ES256)
+	other, _ := clientKey(t, jwa.EdDSA)
+	var now = time.Now()
+
+	verifier := &DPoPVerifier{
+		Replay: &MemoryReplayCache{},
+		Now:    func() time.Time { return now },
+	}
+
+	proof := DPoPProof{Method: "GET", URI: resourceURI + "?q=1#f", AccessToken: "Kz~8mXK1EalYznwH-LC-1fBAo.4Ljp~zsPE_NeO.gxU"}
+	signed, err := proof.Sign(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var request = DPoPRequest{Method: "GET", URI: "HTTPS://RS.example.com:443/resource", AccessToken: proof.AccessToken}
+	token, jkt, err := verifier.Verify(signed, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Payload["htu"] != resourceURI || jkt == "" {
+		t.Errorf("Unexpected proof %v with jkt %s", token.Payload, jkt)
+	}
+
+	if _, _, err = verifier.Verify(signed, request); !errors.Is(err, ErrReplayedToken) {
+		t.Errorf("Expected %s, found %v", ErrReplayedToken, err)
+	}
+
+	sign := func(p DPoPProof, opt *jws.Options) string {
+		signed, err := p.Sign(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	var tests = []struct {
+		name    string
+		proof   string
+		request DPoPRequest
+		err     error
+	}{
+		{"method", sign(proof, opt), DPoPRequest{Method: "POST", URI: resourceURI, AccessToken: proof.AccessToken}, ErrInvalidProof},
+		{"uri", sign(proof, opt), DPoPRequest{Method: "GET", URI: "https://rs.example.com/other", AccessToken: proof.AccessToken}, ErrInvalidProof},
+		{"ath", sign(proof, other), DPoPRequest{Method: "GET", URI: resourceURI, AccessToken: "other"}, ErrInvalidProof},
+		{"no ath", sign(DPoPProof{Method: "GET", URI: resourceURI}, opt), request, ErrInvalidProof},
+		{"old", sign(DPoPProof{Method: "GET", URI: resourceURI, Now: func() time.Time { return now.Add(-time.Hour) }}, opt), DPoPRequest{Method: "GET", URI: resourceURI}, ErrInvalidProof},
+		{"future", sign(DPoPProof{Method: "GET", URI: resourceURI, Now: func() time.Time { return now.Add(time.Hour) }}, opt), DPoPRequest{Method: "GET", URI: resourceURI}, ErrInvalidProof},
+		{"garbage", "a.b.c", DPoPRequest{Method: "GET", URI: resourceURI}, ErrInvalidProof},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := verifier.Verify(tt.proof, tt.request); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Expected %s, found %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func Test_DPoP_Forged(t *testing.T) {
+
+	opt, _ := clientKey(t, jwa.ES256)
+	verifier := &DPoPVerifier{Replay: &MemoryReplayCache{}}
+
+	//A token without the dpop+jwt type is not a proof, even with an embedded key.
+	token := jwt.NewJWT()
+	token.Payload = jwt.Claims{"jti": "id", "htm": "GET", "htu": resourceURI, "iat": time.Now().Unix()}
+	signer := *opt
+	signer.EmbedKey = true
+	if err := jws.Sign(token, &signer); err != nil {
+		t.Fatal(err)
+	}
+	compact, _ := token.CompactSerialization()
+	if _, _, err := verifier.Verify(string(compact), DPoPRequest{Method: "GET", URI: resourceURI}); !errors.Is(err, jwt.ErrInvalidType) {
+		t.Errorf("Expected %s, found %v", jwt.ErrInvalidType, err)
+	}
+
+	//A secret cannot be embedded, so HSXXX proofs cannot be made.
+	secret := jws.BlankOptions()
+	secret.Algorithm = jwa.HS256
+	secret.LoadSecret(make([]byte, 32))
+	if _, err := (DPoPProof{Method: "GET", URI: resourceURI}).Sign(secret); !errors.Is(err, jwa.ErrInvalidKey) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidKey, err)
+	}
+}
+
+func Test_DPoP_Nonce(t *testing.T) {
+
+	opt, _ := clientKey(t, jwa.ES256)
+	verifier := &DPoPVerifier{
+		Replay: &MemoryReplayCache{},
+		Nonce:  func(nonce string) bool { return nonce == "eyJ7S_zG.eyJH0-Z.HX4w-7v" },
+	}
+	var request = DPoPRequest{Method: "POST", URI: "https://as.example.com/token"}
+
+	withoutNonce, _ := DPoPProof{Method: "POST", URI: request.URI}.Sign(opt)
+	if _, _, err := verifier.Verify(withoutNonce, request); !errors.Is(err, ErrUseDPoPNonce) {
+		t.Errorf("Expected %s, found %v", ErrUseDPoPNonce, err)
+	}
+
+	withNonce, _ := DPoPProof{Method: "POST", URI: request.URI, Nonce: "eyJ7S_zG.eyJH0-Z.HX4w-7v"}.Sign(opt)
+	if _, _, err := verifier.Verify(withNonce, request); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_DPoP_Confirmation(t *testing.T) {
+
+	opt, _ := clientKey(t, jwa.ES256)
+	other, _ := clientKey(t, jwa.ES256)
+	verifier := &DPoPVerifier{Replay: &MemoryReplayCache{}}
+	var request = DPoPRequest{Method: "POST", URI: "https://as.example.com/token"}
+
+	//The authorization server binds the token to the key of the proof of the token request.
+	proof, _ := DPoPProof{Method: "POST", URI: request.URI}.Sign(opt)
+	_, jkt, err := verifier.Verify(proof, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accessToken, _ := jwt.AccessToken{
+		Issuer:   "https://as.example.com",
+		Subject:  "fido",
+		ClientID: "s6BhdRkqt3",
+		Audience: []string{"https://rs.example.com"},
+		Lifetime: time.Minute,
+	}.NewJWT()
+	Confirm(accessToken, jkt)
+
+	//The resource server gets it back with a proof of the same key.
+	compact, _ := accessToken.CompactSerialization()
+	received, _ := jwt.Deserialize(compact)
+	if err = CheckConfirmation(received, jkt); err != nil {
+		t.Error(err)
+	}
+
+	proof, _ = DPoPProof{Method: "POST", URI: request.URI}.Sign(other)
+	_, otherJKT, _ := verifier.Verify(proof, request)
+	if err = CheckConfirmation(received, otherJKT); !errors.Is(err, ErrInvalidBinding) {
+		t.Errorf("Expected %s, found %v", ErrInvalidBinding, err)
+	}
+}
@@ -0,0 +1,16 @@
+//Package oauth implements the uses of JWTs made by OAuth 2.0, such as the client
+//assertions of https://tools.ietf.org/html/rfc7523 and the DPoP proofs of https://tools.ietf.org/html/rfc9449
+package oauth
+
+import (
+	"errors"
+)
+
+var (
+	//ErrInvalidAssertion means that a client assertion is malformed or has not the expected claims.
+	ErrInvalidAssertion = errors.New("invalid client assertion")
+	//ErrReplayedToken means that a token with the same "jti" was already accepted.
+	ErrReplayedToken = errors.New("token replayed")
+	//ErrUnknownClient means that there are no keys for the client.
+	ErrUnknownClient = errors.New("unknown client")
+)
@@ -0,0 +1,50 @@
+package oauth
+
+import (
+	"sync"
+	"time"
+)
+
+//ReplayCache remembers the identifiers of the tokens that were accepted, until they expire.
+type ReplayCache interface {
+	//Seen reports whether id was already recorded, and records it until exp otherwise.
+	Seen(id string, exp time.Time) bool
+}
+
+//MemoryReplayCache is a ReplayCache for a single process. The zero value is ready to use.
+//A cluster must share a ReplayCache backed by a common store instead.
+type MemoryReplayCache struct {
+	//Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+
+	mu  sync.Mutex
+	ids map[string]time.Time
+}
+
+//Seen reports whether id was already recorded and hasn't expired. The expired ids are dropped.
+func (c *MemoryReplayCache) Seen(id string, exp time.Time) bool {
+
+	var now = time.Now()
+	if c.Now != nil {
+		now = c.Now()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, until := range c.ids {
+		if !now.Before(until) {
+			delete(c.ids, k)
+		}
+	}
+
+	if _, ok := c.ids[id]; ok {
+		return true
+	}
+
+	if c.ids == nil {
+		c.ids = make(map[string]time.Time)
+	}
+	c.ids[id] = exp
+	return false
+}
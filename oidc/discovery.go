@@ -0,0 +1,152 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jws"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+//DiscoveryPath is where an issuer publishes its metadata: https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderConfig
+const DiscoveryPath = `/.well-known/openid-configuration`
+
+var (
+	//ErrInvalidMetadata means that the provider metadata is malformed or lacks a required value.
+	ErrInvalidMetadata = errors.New("invalid provider metadata")
+	//ErrIssuerMismatch means that the "issuer" of the metadata is not the issuer it was fetched from.
+	ErrIssuerMismatch = errors.New("issuer mismatch")
+	//ErrUnsupportedAlgorithm means that a token is signed with an algorithm the provider doesn't announce.
+	ErrUnsupportedAlgorithm = errors.New("algorithm not supported by the provider")
+)
+
+//maxMetadataSize bounds the metadata documents read by Discover.
+const maxMetadataSize = 1 << 20
+
+//ProviderMetadata are the values of the discovery document of an OpenID Provider used by this package:
+//https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type ProviderMetadata struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                 string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+//Provider is an OpenID Provider configured from its discovery document.
+type Provider struct {
+	Metadata ProviderMetadata
+	//Keys is the JWK Set of its jwks_uri.
+	Keys *jwk.RemoteSet
+}
+
+//Discover fetches the discovery document of issuer with client, http.DefaultClient if nil,
+//and checks it: its "issuer" must be exactly issuer, and it must announce a https
+//jwks_uri and the ID Token signing algorithms, "none" excluded.
+//https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderConfigurationValidation
+func Discover(ctx context.Context, client *http.Client, issuer string) (*Provider, error) {
+
+	if !isHTTPS(issuer) {
+		return nil, ErrInvalidMetadata
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(issuer, "/")+DiscoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ErrInvalidMetadata
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxMetadataSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata ProviderMetadata
+	if err = json.Unmarshal(body, &metadata); err != nil {
+		return nil, ErrInvalidMetadata
+	}
+
+	if metadata.Issuer != issuer {
+		return nil, ErrIssuerMismatch
+	}
+
+	if !isHTTPS(metadata.JWKSURI) || len(metadata.IDTokenSigningAlgValuesSupported) == 0 {
+		return nil, ErrInvalidMetadata
+	}
+	for _, alg := range metadata.IDTokenSigningAlgValuesSupported {
+		if alg == "none" {
+			return nil, ErrInvalidMetadata
+		}
+	}
+
+	return &Provider{
+		Metadata: metadata,
+		Keys:     jwk.NewRemoteSet(metadata.JWKSURI, client),
+	}, nil
+}
+
+//Verifier returns a jws.Verifier with the keys of the provider, which only accepts
+//the signing algorithms it announces. The keys are looked up by "kid", so the keys
+//rotated by the provider are fetched as soon as they are used, and the keys with no
+//"alg" accept the announced algorithms that fit their "kty".
+func (p *Provider) Verifier() jws.Verifier {
+
+	var keys = jws.KeyLookup(p.Keys.Lookup, p.Metadata.IDTokenSigningAlgValuesSupported...)
+	return jws.VerifierFunc(func(j *jwt.JWT) error {
+		if j == nil {
+			return jwa.ErrInvalidInput
+		}
+		for _, signature := range j.Signatures {
+			var header struct {
+				Alg string `json:"alg"`
+			}
+			raw, err := b64.DecodeURLStrict(signature.Protected)
+			if err != nil || json.Unmarshal(raw, &header) != nil {
+				return jws.ErrHeaderNotFound
+			}
+			if !containsString(p.Metadata.IDTokenSigningAlgValuesSupported, header.Alg) {
+				return ErrUnsupportedAlgorithm
+			}
+		}
+		return keys.Verify(j)
+	})
+}
+
+//IDTokenVerifier returns an IDTokenVerifier of the ID Tokens of the provider for clientID.
+func (p *Provider) IDTokenVerifier(clientID string) *IDTokenVerifier {
+	return &IDTokenVerifier{
+		Issuer:   p.Metadata.Issuer,
+		ClientID: clientID,
+		Verifier: p.Verifier(),
+	}
+}
+
+func isHTTPS(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
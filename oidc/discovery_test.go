@@ -0,0 +1,236 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jwk"
+	"github.com/vegaj/JOSE/jws"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+//testProvider serves the discovery document and the set returned by keys, with the metadata changed by change.
+func testProvider(t *testing.T, keys func() jwk.Set, change func(md *ProviderMetadata)) *httptest.Server {
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.Handle("/jwks.json", jwk.NewHandler(keys, time.Minute))
+	mux.HandleFunc(DiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		md := ProviderMetadata{
+			Issuer:                           server.URL,
+			AuthorizationEndpoint:            server.URL + "/authorize",
+			TokenEndpoint:                    server.URL + "/token",
+			JWKSURI:                          server.URL + "/jwks.json",
+			ResponseTypesSupported:           []string{"code"},
+			SubjectTypesSupported:            []string{"public"},
+			IDTokenSigningAlgValuesSupported: []string{jwa.ES256Name},
+		}
+		if change != nil {
+			change(&md)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(md)
+	})
+
+	server = httptest.NewTLSServer(mux)
+	return server
+}
+
+func Test_Discover(t *testing.T) {
+
+	m := jws.NewKeyManager(jwa.ES256, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	server := testProvider(t, m.PublicSet, nil)
+	defer server.Close()
+
+	provider, err := Discover(t.Context(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.Metadata.TokenEndpoint != server.URL+"/token" {
+		t.Errorf("Unexpected metadata: %+v", provider.Metadata)
+	}
+
+	token := jwt.NewJWT()
+	token.Payload = jwt.Claims{
+		"iss": server.URL,
+		"sub": "24400320",
+		"aud": testClientID,
+		"exp": time.Now().Add(time.Minute).Unix(),
+		"iat": time.Now().Unix(),
+	}
+	if err = m.Sign(token); err != nil {
+		t.Fatal(err)
+	}
+	compact, _ := token.CompactSerialization()
+
+	if _, err = provider.IDTokenVerifier(testClientID).Verify(string(compact), IDTokenParams{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Discover_Algorithms(t *testing.T) {
+
+	m := jws.NewKeyManager(jwa.ES384, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	//The provider announces ES256 only, while its key signs with ES384.
+	server := testProvider(t, m.PublicSet, nil)
+	defer server.Close()
+
+	provider, err := Discover(t.Context(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := jwt.NewJWT()
+	token.SetIssuer(server.URL)
+	if err = m.Sign(token); err != nil {
+		t.Fatal(err)
+	}
+	if err = provider.Verifier().Verify(token); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected %s, found %v", ErrUnsupportedAlgorithm, err)
+	}
+}
+
+func Test_Discover_Invalid(t *testing.T) {
+
+	m := jws.NewKeyManager(jwa.ES256, time.Hour, time.Minute)
+
+	var tests = []struct {
+		name   string
+		change func(md *ProviderMetadata)
+		err    error
+	}{
+		{"issuer", func(md *ProviderMetadata) { md.Issuer = "https://evil.example.com" }, ErrIssuerMismatch},
+		{"trailing slash", func(md *ProviderMetadata) { md.Issuer += "/" }, ErrIssuerMismatch},
+		{"jwks_uri", func(md *ProviderMetadata) { md.JWKSURI = "" }, ErrInvalidMetadata},
+		{"plain jwks_uri", func(md *ProviderMetadata) { md.JWKSURI = "http://example.com/jwks.json" }, ErrInvalidMetadata},
+		{"algorithms", func(md *ProviderMetadata) { md.IDTokenSigningAlgValuesSupported = nil }, ErrInvalidMetadata},
+		{"none", func(md *ProviderMetadata) { md.IDTokenSigningAlgValuesSupported = []string{"RS256", "none"} }, ErrInvalidMetadata},
+	}
+
+	for _, tt := range tests {
+		server := testProvider(t, m.PublicSet, tt.change)
+		if _, err := Discover(t.Context(), server.Client(), server.URL); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Expected %s, found %v", tt.name, tt.err, err)
+		}
+		server.Close()
+	}
+
+	if _, err := Discover(t.Context(), nil, "http://example.com"); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("Expected %s, found %v", ErrInvalidMetadata, err)
+	}
+}
+
+//providerToken returns an ID Token of server signed with m.
+func providerToken(t *testing.T, server *httptest.Server, m *jws.KeyManager) string {
+
+	token := jwt.NewJWT()
+	token.Payload = jwt.Claims{
+		"iss": server.URL,
+		"sub": "24400320",
+		"aud": testClientID,
+		"exp": time.Now().Add(time.Minute).Unix(),
+		"iat": time.Now().Unix(),
+	}
+	if err := m.Sign(token); err != nil {
+		t.Fatal(err)
+	}
+	compact, _ := token.CompactSerialization()
+	return string(compact)
+}
+
+func Test_Discover_Rotation(t *testing.T) {
+
+	m := jws.NewKeyManager(jwa.ES256, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	server := testProvider(t, m.PublicSet, nil)
+	defer server.Close()
+
+	provider, err := Discover(t.Context(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.Keys.MinRefresh = time.Nanosecond
+	verifier := provider.IDTokenVerifier(testClientID)
+
+	if _, err = verifier.Verify(providerToken(t, server, m), IDTokenParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	//The provider rotates its key without publishing it in advance,
+	//while the set is still fresh in the cache.
+	key, k, _ := jwk.Generate(jwa.ES256)
+	if err = m.AddKey(jwa.ES256, key, k.KeyID, time.Now().Add(-time.Second), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = verifier.Verify(providerToken(t, server, m), IDTokenParams{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Discover_KeysWithoutAlg(t *testing.T) {
+
+	m := jws.NewKeyManager(jwa.ES256, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	//Many providers leave "alg" out of their keys.
+	keys := func() jwk.Set {
+		set := m.PublicSet()
+		for i := range set.Keys {
+			set.Keys[i].Algorithm = ""
+		}
+		return set
+	}
+
+	server := testProvider(t, keys, func(md *ProviderMetadata) {
+		md.IDTokenSigningAlgValuesSupported = []string{jwa.RS256Name, jwa.ES256Name}
+	})
+	defer server.Close()
+
+	provider, err := Discover(t.Context(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := provider.IDTokenVerifier(testClientID)
+
+	if _, err = verifier.Verify(providerToken(t, server, m), IDTokenParams{}); err != nil {
+		t.Error(err)
+	}
+
+	//An announced algorithm that doesn't fit the key is refused.
+	active, _ := m.Active()
+	rsaKey, _, _ := jwk.Generate(jwa.RS256)
+	opt := jws.BlankOptions()
+	opt.Algorithm = jwa.RS256
+	opt.SignID = active.KeyID
+	if err = opt.SetPrivateKey(rsaKey); err != nil {
+		t.Fatal(err)
+	}
+	token := jwt.NewJWT()
+	token.Payload = jwt.Claims{"iss": server.URL, "sub": "24400320", "aud": testClientID, "exp": time.Now().Add(time.Minute).Unix(), "iat": time.Now().Unix()}
+	if err = jws.Sign(token, opt); err != nil {
+		t.Fatal(err)
+	}
+	compact, _ := token.CompactSerialization()
+	if _, err = verifier.Verify(string(compact), IDTokenParams{}); !errors.Is(err, jwa.ErrInvalidAlgorithm) {
+		t.Errorf("Expected %s, found %v", jwa.ErrInvalidAlgorithm, err)
+	}
+}
@@ -0,0 +1,22 @@
+//Package oidc implements the JWT rules of OpenID Connect for relying parties:
+//https://openid.net/specs/openid-connect-core-1_0.html
+package oidc
+
+import (
+	"errors"
+)
+
+var (
+	//ErrInvalidNonce means that the "nonce" claim is not the one sent in the authentication request.
+	ErrInvalidNonce = errors.New("invalid nonce")
+	//ErrInvalidAuthorizedParty means that the "azp" claim is missing or is not the client.
+	ErrInvalidAuthorizedParty = errors.New("invalid authorized party")
+	//ErrAuthenticationTooOld means that the "auth_time" claim is older than the requested max_age.
+	ErrAuthenticationTooOld = errors.New("authentication too old")
+	//ErrInvalidACR means that the "acr" claim is not one of the requested values.
+	ErrInvalidACR = errors.New("invalid authentication context class")
+	//ErrInvalidHash means that an "at_hash", "c_hash" or "s_hash" claim doesn't match its value.
+	ErrInvalidHash = errors.New("invalid hash claim")
+	//ErrUnsupportedHash means that the signing algorithm has no hash for the hash claims.
+	ErrUnsupportedHash = errors.New("no hash for the signing algorithm")
+)
@@ -0,0 +1,191 @@
+package oidc
+
+import (
+	"crypto"
+	"time"
+
+	"github.com/vegaj/JOSE/b64"
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jws"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+//TokenHash returns the value of the "at_hash", "c_hash" or "s_hash" claims for value: the base64url
+//encoding of the left half of its hash, with the hash of alg, the "alg" of the ID Token.
+//https://openid.net/specs/openid-connect-core-1_0.html#CodeIDToken
+func TokenHash(value, alg string) (string, error) {
+
+	var h crypto.Hash
+	switch alg {
+	case jwa.EdDSAName:
+		//Ed25519 uses SHA-512, as it does internally.
+		h = crypto.SHA512
+	default:
+		impl, err := jwa.GetImplementation(jwa.AlgorithmFromName(alg))
+		if err != nil {
+			return "", err
+		}
+		h = impl.Hash
+	}
+
+	if h == 0 || !h.Available() {
+		return "", ErrUnsupportedHash
+	}
+
+	var hasher = h.New()
+	hasher.Write([]byte(value))
+	var sum = hasher.Sum(nil)
+	return b64.EncodeURL(sum[:len(sum)/2]), nil
+}
+
+//IDTokenVerifier checks the ID Tokens received by a client as described here:
+//https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+type IDTokenVerifier struct {
+	//Issuer is the exact "iss" of the OpenID Provider.
+	Issuer string
+	//ClientID must be in the "aud" claim, and must be the "azp" claim if present.
+	ClientID string
+	//Verifier checks the signature with the keys of the provider, such as jws.KeySet.
+	Verifier jws.Verifier
+	//Leeway is the allowed clock skew.
+	Leeway time.Duration
+	//Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+//IDTokenParams are the values of an authentication request and its response
+//that the ID Token must be bound to. The zero values are not checked.
+type IDTokenParams struct {
+	//Nonce is the "nonce" sent in the authentication request.
+	Nonce string
+	//MaxAge is the "max_age" sent in the authentication request. It requires the "auth_time" claim.
+	MaxAge time.Duration
+	//ACRValues are the "acr_values" requested. The "acr" claim must be one of them.
+	ACRValues []string
+
+	//AccessToken, Code and State are the values returned with the ID Token, to be checked
+	//against the "at_hash", "c_hash" and "s_hash" claims when they are present.
+	AccessToken string
+	Code        string
+	State       string
+	//RequireHashes makes the hash claims of the given values required,
+	//as in the implicit and hybrid flows.
+	RequireHashes bool
+}
+
+//Verify returns the ID Token of raw, a compact serialization, once its signature,
+//its claims and its bindings to p are checked. The errors about the claims are *jwt.ValidationError.
+func (v *IDTokenVerifier) Verify(raw string, p IDTokenParams) (*jwt.JWT, error) {
+
+	if v.Verifier == nil || v.Issuer == "" || v.ClientID == "" {
+		return nil, jwa.ErrInvalidInput
+	}
+
+	token, err := jwt.Deserialize([]byte(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	//The checks below read the header of the only signature.
+	if len(token.Signatures) != 1 {
+		return nil, jws.ErrSignatureNotFound
+	}
+
+	if err = v.Verifier.Verify(&token); err != nil {
+		return nil, err
+	}
+
+	//An access token of RFC 9068 is never accepted as an ID Token.
+	if typ, _ := token.Signatures[0].Header["typ"].(string); jwt.TypeMatches(typ, jwt.TypeAccessToken) {
+		return nil, jwt.ErrInvalidType
+	}
+
+	var now = time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+
+	var validator = jwt.Validator{
+		Issuer:   v.Issuer,
+		Audience: v.ClientID,
+		Required: []string{"sub", "exp", "iat"},
+		Leeway:   v.Leeway,
+		Now:      func() time.Time { return now },
+	}
+	if err = validator.Validate(token); err != nil {
+		return nil, err
+	}
+
+	//With several audiences, the client must be the authorized party.
+	aud, _ := jwt.StringList(token.Payload["aud"])
+	azp, hasAZP := token.Payload["azp"].(string)
+	if (hasAZP && azp != v.ClientID) || (!hasAZP && len(aud) > 1) {
+		return nil, &jwt.ValidationError{Claim: "azp", Err: ErrInvalidAuthorizedParty}
+	}
+
+	if p.Nonce != "" {
+		if nonce, _ := token.Payload["nonce"].(string); nonce != p.Nonce {
+			return nil, &jwt.ValidationError{Claim: "nonce", Err: ErrInvalidNonce}
+		}
+	}
+
+	if p.MaxAge > 0 {
+		authTime, ok := jwt.NumericDate(token.Payload["auth_time"])
+		if !ok {
+			return nil, &jwt.ValidationError{Claim: "auth_time", Err: jwt.ErrMissingClaim}
+		}
+		if now.Sub(time.Unix(authTime, 0)) > p.MaxAge+v.Leeway {
+			return nil, &jwt.ValidationError{Claim: "auth_time", Err: ErrAuthenticationTooOld}
+		}
+	}
+
+	if len(p.ACRValues) != 0 {
+		acr, _ := token.Payload["acr"].(string)
+		if !containsString(p.ACRValues, acr) {
+			return nil, &jwt.ValidationError{Claim: "acr", Err: ErrInvalidACR}
+		}
+	}
+
+	alg, _ := token.Signatures[0].Header["alg"].(string)
+	for claim, value := range map[string]string{"at_hash": p.AccessToken, "c_hash": p.Code, "s_hash": p.State} {
+		if err = checkHash(token, claim, value, alg, p.RequireHashes); err != nil {
+			return nil, err
+		}
+	}
+
+	return &token, nil
+}
+
+//checkHash compares the hash claim with the hash of value, if both are present.
+func checkHash(token jwt.JWT, claim, value, alg string, required bool) error {
+
+	if value == "" {
+		return nil
+	}
+
+	got, ok := token.Payload[claim].(string)
+	if !ok {
+		if required {
+			return &jwt.ValidationError{Claim: claim, Err: jwt.ErrMissingClaim}
+		}
+		return nil
+	}
+
+	want, err := TokenHash(value, alg)
+	if err != nil {
+		return &jwt.ValidationError{Claim: claim, Err: err}
+	}
+	if got != want {
+		return &jwt.ValidationError{Claim: claim, Err: ErrInvalidHash}
+	}
+	return nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package oidc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vegaj/JOSE/jwa"
+	"github.com/vegaj/JOSE/jws"
+	"github.com/vegaj/JOSE/jwt"
+)
+
+const (
+	testIssuer   = "https://server.example.com"
+	testClientID = "s6BhdRkqt3"
+)
+
+//Test vectors of the examples of https://openid.net/specs/openid-connect-core-1_0.html#code-id_tokenExample
+func Test_TokenHash(t *testing.T) {
+
+	var tests = []struct {
+		value, alg, hash string
+	}{
+		{"jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y", jwa.RS256Name, "77QmUPtjPfzWtF2AnpK9RQ"},
+		{"Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk", jwa.RS256Name, "LDktKdoQak3Pk0cnXxCltA"},
+	}
+
+	for _, tt := range tests {
+		hash, err := TokenHash(tt.value, tt.alg)
+		if err != nil || hash != tt.hash {
+			t.Errorf("Expected %s, found %s: %v", tt.hash, hash, err)
+		}
+	}
+
+	if hash, _ := TokenHash("value", jwa.EdDSAName); len(hash) != 43 {
+		t.Errorf("Expected half of a SHA-512 hash, found %s", hash)
+	}
+	if _, err := TokenHash("value", jwa.MLDSA44Name); !errors.Is(err, ErrUnsupportedHash) {
+		t.Errorf("Expected %s, found %v", ErrUnsupportedHash, err)
+	}
+}
+
+func Test_IDToken_Verify(t *testing.T) {
+
+	m := jws.NewKeyManager(jwa.ES384, time.Hour, time.Minute)
+	if err := m.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var now = time.Now()
+	atHash, _ := TokenHash("access", jwa.ES384Name)
+	cHash, _ := TokenHash("code", jwa.ES384Name)
+
+	issue := func(change func(c jwt.Claims)) string {
+		token := jwt.NewJWT()
+		token.Payload = jwt.Claims{
+			"iss":       testIssuer,
+			"sub":       "24400320",
+			"aud":       testClientID,
+			"nonce":     "n-0S6_WzA2Mj",
+			"exp":       now.Add(10 * time.Minute).Unix(),
+			"iat":       now.Unix(),
+			"auth_time": now.Add(-time.Minute).Unix(),
+			"acr":       "urn:mace:incommon:iap:silver",
+			"at_hash":   atHash,
+			"c_hash":    cHash,
+		}
+		if change != nil {
+			change(token.Payload)
+		}
+		if err := m.Sign(token); err != nil {
+			t.Fatal(err)
+		}
+		compact, _ := token.CompactSerialization()
+		return string(compact)
+	}
+
+	v := &IDTokenVerifier{
+		Issuer:   testIssuer,
+		ClientID: testClientID,
+		Verifier: jws.KeySet(m.PublicSet),
+		Now:      func() time.Time { return now },
+	}
+
+	var params = IDTokenParams{
+		Nonce:       "n-0S6_WzA2Mj",
+		MaxAge:      5 * time.Minute,
+		ACRValues:   []string{"urn:mace:incommon:iap:silver", "urn:mace:incommon:iap:bronze"},
+		AccessToken: "access",
+		Code:        "code",
+		State:       "state",
+	}
+
+	token, err := v.Verify(issue(nil), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Subject() != "24400320" {
+		t.Errorf("Unexpected subject: %v", token.Payload)
+	}
+
+	var tests = []struct {
+		name   string
+		change func(c jwt.Claims)
+		params func(p *IDTokenParams)
+		err    error
+	}{
+		{"issuer", func(c jwt.Claims) { c["iss"] = "https://other.example.com" }, nil, jwt.ErrInvalidIssuer},
+		{"audience", func(c jwt.Claims) { c["aud"] = "other" }, nil, jwt.ErrInvalidAudience},
+		{"no azp", func(c jwt.Claims) { c["aud"] = []string{testClientID, "other"} }, nil, ErrInvalidAuthorizedParty},
+		{"azp", func(c jwt.Claims) { c["azp"] = "other" }, nil, ErrInvalidAuthorizedParty},
+		{"expired", func(c jwt.Claims) { c["exp"] = now.Add(-time.Minute).Unix() }, nil, jwt.ErrExpired},
+		{"no iat", func(c jwt.Claims) { delete(c, "iat") }, nil, jwt.ErrMissingClaim},
+		{"nonce", func(c jwt.Claims) { c["nonce"] = "other" }, nil, ErrInvalidNonce},
+		{"no auth_time", func(c jwt.Claims) { delete(c, "auth_time") }, nil, jwt.ErrMissingClaim},
+		{"max_age", func(c jwt.Claims) { c["auth_time"] = now.Add(-time.Hour).Unix() }, nil, ErrAuthenticationTooOld},
+		{"acr", func(c jwt.Claims) { c["acr"] = "urn:mace:incommon:iap:gold" }, nil, ErrInvalidACR},
+		{"at_hash", func(c jwt.Claims) { c["at_hash"] = cHash }, nil, ErrInvalidHash},
+		{"c_hash", nil, func(p *IDTokenParams) { p.Code = "other" }, ErrInvalidHash},
+		{"s_hash", nil, func(p *IDTokenParams) { p.RequireHashes = true }, jwt.ErrMissingClaim},
+	}
+
+	for _, tt := range tests {
+		var p = params
+		if tt.params != nil {
+			tt.params(&p)
+		}
+		if _, err := v.Verify(issue(tt.change), p); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Expected %s, found %v", tt.name, tt.err, err)
+		}
+	}
+
+	//Several audiences are accepted when the client is the authorized party.
+	multiple := issue(func(c jwt.Claims) {
+		c["aud"] = []string{testClientID, "other"}
+		c["azp"] = testClientID
+	})
+	if _, err = v.Verify(multiple, params); err != nil {
+		t.Error(err)
+	}
+
+	//An access token of the same issuer cannot pass for an ID Token.
+	access := jwt.NewJWT()
+	access.Header["typ"] = "application/at+jwt"
+	for k, c := range map[string]interface{}{"iss": testIssuer, "sub": "24400320", "aud": testClientID, "exp": now.Add(time.Minute).Unix(), "iat": now.Unix()} {
+		access.Payload[k] = c
+	}
+	if err = m.Sign(access); err != nil {
+		t.Fatal(err)
+	}
+	compact, _ := access.CompactSerialization()
+	if _, err = v.Verify(string(compact), IDTokenParams{}); !errors.Is(err, jwt.ErrInvalidType) {
+		t.Errorf("Expected %s, found %v", jwt.ErrInvalidType, err)
+	}
+
+	//An unsigned token is refused even by a Verifier that accepts everything.
+	unsigned := jwt.NewJWT()
+	unsigned.Header["alg"] = "none"
+	unsigned.Payload = jwt.Claims{"iss": testIssuer, "sub": "24400320", "aud": testClientID, "exp": now.Add(time.Minute).Unix(), "iat": now.Unix()}
+	compact, _ = unsigned.CompactSerialization()
+	lax := &IDTokenVerifier{Issuer: testIssuer, ClientID: testClientID, Verifier: jws.VerifierFunc(func(*jwt.JWT) error { return nil })}
+	if _, err = lax.Verify(string(compact), IDTokenParams{}); !errors.Is(err, jws.ErrSignatureNotFound) {
+		t.Errorf("Expected %s, found %v", jws.ErrSignatureNotFound, err)
+	}
+}
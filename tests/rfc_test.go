@@ -115,9 +115,17 @@ func Test_ECDSA_512(t *testing.T) {
 		t.Error(err)
 	}
 
-	signature := append(r.Bytes(), s.Bytes()...)
+	//r and s are left padded to 66 octets each: https://tools.ietf.org/html/rfc7515#appendix-A.4.1
+	signature, err := jwa.EncodeEllipticSignature(r, s, jwa.ES512)
+	if err != nil || len(signature) != jwa.ESP521Octets {
+		t.Fatalf("Unexpected signature: %d octets, %v", len(signature), err)
+	}
+
+	nr, ns, err := jwa.DecodeEllipticSignature(signature, jwa.ES512)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	nr, ns := big.NewInt(0).SetBytes(signature[:jwa.ESP521Octets/2]), big.NewInt(0).SetBytes(signature[jwa.ESP521Octets/2:])
 	if err := jwa.EllipticVerify(asciiMsg, &key.PublicKey, nr, ns, jwa.ES512); err != nil {
 		t.Error(err)
 	}